@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -73,12 +74,30 @@ func NewGormDB() *gorm.DB {
 	}
 
 	log.Println("Running database auto-migration...")
-	
+
+	backfillRewards := !db.Migrator().HasColumn(&levelinfra.UserLevelProgress{}, "reward_earned")
+	if err := levelinfra.DedupeLevelProgress(db); err != nil {
+		log.Fatal("Failed to deduplicate level progress:", err)
+	}
+
 	err = db.AutoMigrate(
 		authinfra.User{},
+		authinfra.UserIdentity{},
+		authinfra.RefreshToken{},
+		authinfra.Session{},
+		authinfra.UserToken{},
+		authinfra.LoginThrottle{},
+		authinfra.LoginAttempt{},
+		authinfra.RecoveryCode{},
 		levelinfra.Level{},
 		levelinfra.UserLevelProgress{},
+		levelinfra.LevelAttempt{},
 		levelinfra.UserReward{},
+		levelinfra.CoinTransaction{},
+		levelinfra.ShopItem{},
+		levelinfra.UserInventoryItem{},
+		levelinfra.LevelHint{},
+		levelinfra.UserHintUnlock{},
 		notificationinfra.Notification{},
 		notificationinfra.UserNotificationPreference{},
 		notificationinfra.UserFCMToken{},
@@ -87,6 +106,31 @@ func NewGormDB() *gorm.DB {
 	if err != nil {
 		log.Fatal("Failed to auto-migrate database:", err)
 	}
+	if err := authinfra.MigrateLegacyIdentities(db); err != nil {
+		log.Fatal("Failed to migrate user identities:", err)
+	}
+	if err := authinfra.DropLegacyIdentityColumns(db); errors.Is(err, authinfra.ErrLegacyIdentitiesNotCopied) {
+		log.Println("Keeping legacy identity columns:", err)
+	} else if err != nil {
+		log.Fatal("Failed to drop legacy identity columns:", err)
+	}
+	if err := authinfra.EnsureUsernameIndex(db); err != nil {
+		log.Fatal("Failed to add the username index:", err)
+	}
+	if err := authinfra.EnsureEmailIndex(db); err != nil {
+		log.Fatal("Failed to add the email index:", err)
+	}
+	if backfillRewards {
+		if err := levelinfra.BackfillRewardEarned(db); err != nil {
+			log.Fatal("Failed to backfill level rewards:", err)
+		}
+	}
+	if err := levelinfra.BackfillLevelReached(db); err != nil {
+		log.Fatal("Failed to backfill unlocked levels:", err)
+	}
+	if err := levelinfra.BackfillCoinLedger(db); err != nil {
+		log.Fatal("Failed to backfill coin ledger:", err)
+	}
 	log.Println("Database auto-migration completed successfully!")
 
 	gormDB = db
@@ -2,13 +2,18 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"gorm.io/gorm"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
 )
 
 func mustStartPostgresContainer() (func(context.Context) error, error) {
@@ -36,6 +41,7 @@ func mustStartPostgresContainer() (func(context.Context) error, error) {
 	database = dbName
 	password = dbPwd
 	username = dbUser
+	schema = "public"
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -66,6 +72,60 @@ func TestMain(m *testing.M) {
 	}
 }
 
+// newTestDB returns the migrated test database with every table emptied, so
+// each test starts from scratch.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := NewGormDB()
+	var tables []string
+	if err := db.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema()").Scan(&tables).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("TRUNCATE " + strings.Join(tables, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createLevels adds a level paying reward for each number.
+func createLevels(t *testing.T, repo *levelinfra.PlantRepository, reward int, numbers ...int) []levelinfra.Level {
+	t.Helper()
+	levels := make([]levelinfra.Level, 0, len(numbers))
+	for _, number := range numbers {
+		level := levelinfra.Level{
+			LevelNumber: number,
+			Riddle:      fmt.Sprintf("Riddle %d", number),
+			PlantName:   fmt.Sprintf("Plant %d", number),
+			Reward:      reward,
+		}
+		if err := repo.CreateLevel(&level); err != nil {
+			t.Fatal(err)
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// coinEntries returns the user's ledger in the order it was written.
+func coinEntries(t *testing.T, db *gorm.DB, userID uint) []levelinfra.CoinTransaction {
+	t.Helper()
+	var entries []levelinfra.CoinTransaction
+	if err := db.Where("user_id = ?", userID).Order("created_at, id").Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+// balance returns the user's cached coin balance.
+func balance(t *testing.T, repo *levelinfra.PlantRepository, userID uint) int {
+	t.Helper()
+	reward, err := repo.GetUserReward(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reward.TotalRewards
+}
+
 func TestNew(t *testing.T) {
 	srv := New()
 	if srv == nil {
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestUnlockNextHintAppliesPenalty(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	all := createLevels(t, repo, 100, 1, 2)
+	level := all[0]
+	for _, hint := range []levelinfra.LevelHint{
+		{LevelID: level.ID, Type: levelinfra.HintText, Content: "It climbs walls", Cost: 10, RewardPenalty: 25},
+		{LevelID: level.ID, Type: levelinfra.HintFirstLetter, Cost: 20, RewardPenalty: 25},
+		{LevelID: all[1].ID, Type: levelinfra.HintLength, Cost: 5, RewardPenalty: 10},
+	} {
+		if err := repo.CreateHint(&hint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	item := createShopItem(t, repo, "Hint", levelinfra.ShopItemHint, 5)
+	const userID = 1
+	if _, err := repo.AdjustCoins(userID, 50, "welcome", 99, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.UnlockNextHint(userID, &all[1], false); !errors.Is(err, levelinfra.ErrLevelLocked) {
+		t.Errorf("expected hints of a locked level to be refused, got %v", err)
+	}
+
+	first, err := repo.UnlockNextHint(userID, &level, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Content != "It climbs walls" || first.Position != 1 {
+		t.Errorf("expected the first hint's content, got %+v", first)
+	}
+	if got := balance(t, repo, userID); got != 40 {
+		t.Errorf("expected the hint to cost 10 coins, got a balance of %d", got)
+	}
+
+	if _, err := repo.PurchaseItem(userID, item.ID, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.UnlockNextHint(userID, &level, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Content != "P" {
+		t.Errorf("expected the first letter of %q, got %+v", level.PlantName, second)
+	}
+	if got := balance(t, repo, userID); got != 35 {
+		t.Errorf("expected the hint item to be used instead of coins, got a balance of %d", got)
+	}
+	if inventory, err := repo.GetInventory(userID); err != nil || len(inventory) != 0 {
+		t.Errorf("expected the hint item to be used up, got %+v %v", inventory, err)
+	}
+	if _, err := repo.UnlockNextHint(userID, &level, false); !errors.Is(err, levelinfra.ErrNoMoreHints) {
+		t.Errorf("expected ErrNoMoreHints, got %v", err)
+	}
+
+	// Two hints at 25% each halve the reward.
+	completion, err := repo.CompleteLevel(userID, level.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion.Reward != 50 {
+		t.Errorf("expected a reward of 50, got %d", completion.Reward)
+	}
+	if got := balance(t, repo, userID); got != 85 {
+		t.Errorf("expected a balance of 85, got %d", got)
+	}
+	if _, err := repo.UnlockNextHint(userID, &level, false); !errors.Is(err, levelinfra.ErrLevelAlreadyCompleted) {
+		t.Errorf("expected hints of a completed level to be refused, got %v", err)
+	}
+	if _, err := repo.UnlockNextHint(userID, &all[1], true); !errors.Is(err, levelinfra.ErrNoHintItems) {
+		t.Errorf("expected ErrNoHintItems without a hint item, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	authinfra "plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func TestMigrateLegacyIdentities(t *testing.T) {
+	db := newTestDB(t)
+	users := authinfra.NewUserRepository(db)
+	google := createUser(t, users, "fern")
+	device := createUser(t, users, "guest")
+	taken := createUser(t, users, "moss")
+
+	// Bring back the columns identities lived in before user_identities.
+	for _, column := range []string{"google_id", "android_id"} {
+		if err := db.Exec("ALTER TABLE users ADD COLUMN " + column + " varchar(255)").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Exec("UPDATE users SET email = ?, google_id = ? WHERE id = ?", "fern@example.com", "g-1", google.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	// android_id had a unique index, but the restored column does not.
+	if err := db.Exec("UPDATE users SET android_id = ? WHERE id IN (?, ?)", "a-1", device.ID, taken.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := authinfra.MigrateLegacyIdentities(db); err != nil {
+		t.Fatal(err)
+	}
+	identities, err := users.GetUserIdentities(google.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 1 || identities[0].Subject != "g-1" || !identities[0].EmailVerified ||
+		identities[0].Email == nil || *identities[0].Email != "fern@example.com" {
+		t.Fatalf("expected the Google identity to keep its email as verified, got %+v", identities)
+	}
+
+	// Only one of the two users sharing a device ID can get its identity, so
+	// android_id is kept while google_id goes.
+	err = authinfra.DropLegacyIdentityColumns(db)
+	if !errors.Is(err, authinfra.ErrLegacyIdentitiesNotCopied) {
+		t.Fatalf("expected android_id to be kept, got %v", err)
+	}
+	if db.Migrator().HasColumn(&authinfra.User{}, "google_id") {
+		t.Error("expected google_id to be dropped")
+	}
+	if !db.Migrator().HasColumn(&authinfra.User{}, "android_id") {
+		t.Fatal("expected android_id to be kept")
+	}
+
+	if err := db.Exec("UPDATE users SET android_id = NULL WHERE id = ?", taken.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := authinfra.DropLegacyIdentityColumns(db); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasColumn(&authinfra.User{}, "android_id") {
+		t.Error("expected android_id to be dropped once every identity was copied")
+	}
+}
+
+func TestSignInWithIdentityMatchesEmailIgnoringCase(t *testing.T) {
+	db := newTestDB(t)
+	users := authinfra.NewUserRepository(db)
+	jane := createUser(t, users, "jane")
+	if err := db.Exec("UPDATE users SET email = ?, email_verified_at = NOW() WHERE id = ?", "jane@example.com", jane.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	reported := "Jane@Example.com"
+	if found, err := users.GetUserByEmail(reported); err != nil || found.ID != jane.ID {
+		t.Fatalf("expected %s to find jane, got %+v %v", reported, found, err)
+	}
+	user, err := users.SignInWithIdentity(&authinfra.UserIdentity{
+		Provider:      authinfra.ProviderGoogle,
+		Subject:       "g-jane",
+		Email:         &reported,
+		EmailVerified: true,
+	}, &authinfra.User{Username: "Jane", Email: &reported, Role: authinfra.RolePlayer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != jane.ID {
+		t.Errorf("expected the identity to be linked to jane, got a new account %d", user.ID)
+	}
+
+	other := createUser(t, users, "janet")
+	if err := db.Exec("UPDATE users SET email = ? WHERE id = ?", "JANE@example.com", other.ID).Error; err == nil {
+		t.Error("expected the email index to refuse an address differing only by case")
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestCoinLedgerAppendsAndReconciles(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	const userID, adminID = 1, 99
+
+	if _, err := repo.AdjustCoins(userID, 50, "welcome", adminID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AdjustCoins(userID, 30, "event", adminID, "event-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AdjustCoins(userID, 30, "event", adminID, "event-1"); !errors.Is(err, levelinfra.ErrDuplicateCoinTransaction) {
+		t.Errorf("expected the repeated key to be refused, got %v", err)
+	}
+	if _, err := repo.AdjustCoins(userID, -100, "too much", adminID, ""); !errors.Is(err, levelinfra.ErrInsufficientCoins) {
+		t.Errorf("expected the overdraft to be refused, got %v", err)
+	}
+
+	entries := coinEntries(t, db, userID)
+	if len(entries) != 2 || entries[0].BalanceAfter != 50 || entries[1].BalanceAfter != 80 {
+		t.Fatalf("expected entries with running balances 50 and 80, got %+v", entries)
+	}
+	if got := balance(t, repo, userID); got != 80 {
+		t.Errorf("expected a cached balance of 80, got %d", got)
+	}
+
+	if err := db.Model(&levelinfra.UserReward{}).Where("user_id = ?", userID).
+		Update("total_rewards", 500).Error; err != nil {
+		t.Fatal(err)
+	}
+	drift, err := repo.FindBalanceDrift(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 1 || drift[0].CachedBalance != 500 || drift[0].LedgerBalance != 80 {
+		t.Fatalf("expected drift from 500 to 80, got %+v", drift)
+	}
+
+	fixed, err := repo.ReconcileBalance(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed.CachedBalance != 500 || fixed.LedgerBalance != 80 {
+		t.Errorf("unexpected reconciliation %+v", fixed)
+	}
+	if got := balance(t, repo, userID); got != 80 {
+		t.Errorf("expected the balance to be reset to 80, got %d", got)
+	}
+	if drift, err := repo.FindBalanceDrift(10); err != nil || len(drift) != 0 {
+		t.Errorf("expected no drift after reconciling, got %+v %v", drift, err)
+	}
+}
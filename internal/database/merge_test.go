@@ -0,0 +1,144 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	authinfra "plantgo-backend/internal/modules/auth/infrastructure"
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+// createUser adds a player account.
+func createUser(t *testing.T, repo *authinfra.UserRepository, username string) *authinfra.User {
+	t.Helper()
+	user := &authinfra.User{Username: username, Role: authinfra.RolePlayer}
+	if err := repo.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestMergeUsers(t *testing.T) {
+	db := newTestDB(t)
+	users := authinfra.NewUserRepository(db)
+	levels := levelinfra.NewPlantRepository(db)
+	all := createLevels(t, levels, 100, 1, 2, 3)
+	guest := createUser(t, users, "guest")
+	target := createUser(t, users, "fern")
+
+	complete := func(userID uint, level levelinfra.Level) {
+		t.Helper()
+		if _, err := levels.CompleteLevel(userID, level.ID, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Both accounts completed level 1; only the guest got to level 2.
+	complete(target.ID, all[0])
+	complete(guest.ID, all[0])
+	complete(guest.ID, all[1])
+
+	if err := users.MergeUsers(guest.ID, target.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []levelinfra.UserLevelProgress
+	if err := db.Where("user_id = ?", target.ID).Order("level_id").Find(&progress).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 2 || !progress[0].IsCompleted || !progress[1].IsCompleted ||
+		progress[0].RewardEarned != 100 || progress[1].RewardEarned != 100 {
+		t.Fatalf("expected levels 1 and 2 completed once each, got %+v", progress)
+	}
+
+	// Level 1 was paid to both accounts, so the merge takes one payment back.
+	if got := balance(t, levels, target.ID); got != 200 {
+		t.Errorf("expected a merged balance of 200, got %d", got)
+	}
+	entries := coinEntries(t, db, target.ID)
+	if len(entries) != 2 {
+		t.Fatalf("expected the target's own entry and one transfer, got %+v", entries)
+	}
+	if last := entries[1]; last.Reason != levelinfra.CoinReasonAccountMerge || last.Amount != 100 || last.BalanceAfter != 200 {
+		t.Errorf("expected a transfer of 100 leaving 200, got %+v", last)
+	}
+
+	// The guest's ledger is left as it was and closed.
+	closed := coinEntries(t, db, guest.ID)
+	if len(closed) != 3 || closed[0].BalanceAfter != 100 || closed[1].BalanceAfter != 200 {
+		t.Fatalf("expected the guest's two rewards and a closing entry, got %+v", closed)
+	}
+	if last := closed[2]; last.Reason != levelinfra.CoinReasonAccountClosed || last.Amount != -200 || last.BalanceAfter != 0 {
+		t.Errorf("expected a closing entry of -200, got %+v", last)
+	}
+	if drift, err := levels.FindBalanceDrift(10); err != nil || len(drift) != 0 {
+		t.Errorf("expected no balance drift, got %+v %v", drift, err)
+	}
+
+	reward, err := levels.GetUserReward(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reward.LevelReached != 3 {
+		t.Errorf("expected level 3 to be unlocked, got %d", reward.LevelReached)
+	}
+	if _, err := levels.GetUserReward(guest.ID); err == nil {
+		t.Error("expected the guest's rewards to be gone")
+	}
+	if _, err := users.GetUserByID(guest.ID); err == nil {
+		t.Error("expected the guest account to be deleted")
+	}
+}
+
+func TestReversalSurvivesMerge(t *testing.T) {
+	db := newTestDB(t)
+	users := authinfra.NewUserRepository(db)
+	levels := levelinfra.NewPlantRepository(db)
+	guest := createUser(t, users, "guest")
+	target := createUser(t, users, "fern")
+	const adminID = 99
+
+	reversed, err := levels.AdjustCoins(guest.ID, 50, "welcome", adminID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := levels.AdjustCoins(guest.ID, 30, "event", adminID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := levels.ReverseCoinTransaction(reversed.ID, "mistake", adminID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := levels.AdjustCoins(target.ID, 10, "welcome", adminID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.MergeUsers(guest.ID, target.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := balance(t, levels, target.ID); got != 40 {
+		t.Fatalf("expected a merged balance of 40, got %d", got)
+	}
+
+	if _, err := levels.ReverseCoinTransaction(reversed.ID, "again", adminID); !errors.Is(err, levelinfra.ErrAlreadyReversed) {
+		t.Errorf("expected the second reversal to be refused, got %v", err)
+	}
+	closing := coinEntries(t, db, guest.ID)
+	if _, err := levels.ReverseCoinTransaction(closing[len(closing)-1].ID, "undo", adminID); !errors.Is(err, levelinfra.ErrAlreadyReversed) {
+		t.Errorf("expected the closing entry to be irreversible, got %v", err)
+	}
+
+	// An entry the guest never reversed is reversed on the merged account.
+	reversal, err := levels.ReverseCoinTransaction(kept.ID, "mistake", adminID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversal.UserID != target.ID || reversal.Amount != -30 || reversal.BalanceAfter != 10 {
+		t.Errorf("expected -30 on the merged account, got %+v", reversal)
+	}
+	if _, err := levels.GetUserReward(guest.ID); err == nil {
+		t.Error("expected the guest to have no rewards again")
+	}
+	if drift, err := levels.FindBalanceDrift(10); err != nil || len(drift) != 0 {
+		t.Errorf("expected no balance drift, got %+v %v", drift, err)
+	}
+}
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestCompleteLevelIsIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	levels := createLevels(t, repo, 100, 1, 2)
+	const userID = 1
+
+	first, err := repo.CompleteLevel(userID, levels[0].ID, "tap-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Replayed || first.Reward != 100 {
+		t.Fatalf("expected a fresh completion paying 100, got %+v", first)
+	}
+
+	replay, err := repo.CompleteLevel(userID, levels[0].ID, "tap-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replay.Replayed || replay.Reward != first.Reward || replay.IdempotencyKey != "tap-1" {
+		t.Errorf("expected the retry to replay %+v, got %+v", first, replay)
+	}
+
+	for _, key := range []string{"tap-2", ""} {
+		if _, err := repo.CompleteLevel(userID, levels[0].ID, key); !errors.Is(err, levelinfra.ErrLevelAlreadyCompleted) {
+			t.Errorf("key %q: expected ErrLevelAlreadyCompleted, got %v", key, err)
+		}
+	}
+	if _, err := repo.CompleteLevel(userID, levels[1].ID, "tap-1"); !errors.Is(err, levelinfra.ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused for another level, got %v", err)
+	}
+
+	if entries := coinEntries(t, db, userID); len(entries) != 1 || entries[0].Amount != 100 {
+		t.Errorf("expected one ledger entry of 100, got %+v", entries)
+	}
+	if got := balance(t, repo, userID); got != 100 {
+		t.Errorf("expected a balance of 100, got %d", got)
+	}
+}
+
+func TestCompleteLevelWithAttemptDoubleTap(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	level := createLevels(t, repo, 100, 1)[0]
+	const userID, taps = 1, 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, taps)
+	for i := range taps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt := &levelinfra.LevelAttempt{
+				UserID:    userID,
+				LevelID:   level.ID,
+				Method:    levelinfra.AttemptAnswer,
+				Answer:    level.PlantName,
+				IsCorrect: true,
+			}
+			_, errs[i] = repo.CompleteLevelWithAttempt(attempt, &level, level.Reward, fmt.Sprintf("tap-%d", i))
+		}()
+	}
+	wg.Wait()
+
+	completed := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			completed++
+		case !errors.Is(err, levelinfra.ErrLevelAlreadyCompleted):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if completed != 1 {
+		t.Errorf("expected exactly one tap to complete the level, got %d", completed)
+	}
+
+	var progress, attempts int64
+	db.Model(&levelinfra.UserLevelProgress{}).Where("user_id = ?", userID).Count(&progress)
+	db.Model(&levelinfra.LevelAttempt{}).Where("user_id = ?", userID).Count(&attempts)
+	if progress != 1 || attempts != 1 {
+		t.Errorf("expected one progress row and one attempt, got %d and %d", progress, attempts)
+	}
+	if entries := coinEntries(t, db, userID); len(entries) != 1 {
+		t.Errorf("expected the reward to be paid once, got %+v", entries)
+	}
+	if got := balance(t, repo, userID); got != 100 {
+		t.Errorf("expected a balance of 100, got %d", got)
+	}
+}
+
+func TestCompleteLevelRejectsLockedLevelsAcrossGaps(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	levels := createLevels(t, repo, 10, 1, 2, 3, 5)
+	if err := repo.DeleteLevel(levels[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	const userID = 1
+
+	complete := func(level levelinfra.Level) error {
+		_, err := repo.CompleteLevel(userID, level.ID, "")
+		return err
+	}
+	reached := func() int {
+		reward, err := repo.GetUserReward(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reward.LevelReached
+	}
+
+	if err := complete(levels[1]); !errors.Is(err, levelinfra.ErrLevelLocked) {
+		t.Fatalf("expected level 2 to be locked for a new player, got %v", err)
+	}
+	if err := complete(levels[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := reached(); got != 2 {
+		t.Errorf("expected level 2 to be unlocked, got %d", got)
+	}
+	if err := complete(levels[3]); !errors.Is(err, levelinfra.ErrLevelLocked) {
+		t.Fatalf("expected level 5 to be locked, got %v", err)
+	}
+
+	// Level 3 was deleted and there is no level 4, so level 5 comes next.
+	if err := complete(levels[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := reached(); got != 5 {
+		t.Errorf("expected level 5 to be unlocked across the gap, got %d", got)
+	}
+	if unlocked, err := repo.IsLevelUnlocked(userID, &levels[3]); err != nil || !unlocked {
+		t.Errorf("expected level 5 to be playable, got %v %v", unlocked, err)
+	}
+	if err := complete(levels[3]); err != nil {
+		t.Errorf("expected level 5 to complete, got %v", err)
+	}
+	if got := balance(t, repo, userID); got != 30 {
+		t.Errorf("expected three rewards of 10, got %d", got)
+	}
+}
+
+func TestBackfillLevelReachedUnlocksTheNextLevel(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	levels := createLevels(t, repo, 10, 1, 2, 3)
+	const userID, finishedID = 1, 2
+
+	// Rows as the old completion left them: level_reached is the highest
+	// level completed rather than the next one to play.
+	seed := func(userID uint, completed ...levelinfra.Level) {
+		t.Helper()
+		for _, level := range completed {
+			progress := levelinfra.UserLevelProgress{UserID: userID, LevelID: level.ID, IsCompleted: true, RewardEarned: level.Reward}
+			if err := db.Create(&progress).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		reward := levelinfra.UserReward{UserID: userID, LevelReached: completed[len(completed)-1].LevelNumber}
+		if err := db.Create(&reward).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	seed(userID, levels[0], levels[1])
+	seed(finishedID, levels...)
+
+	if _, err := repo.CompleteLevel(userID, levels[2].ID, ""); !errors.Is(err, levelinfra.ErrLevelLocked) {
+		t.Fatalf("expected level 3 to be locked before the backfill, got %v", err)
+	}
+	for range 2 {
+		if err := levelinfra.BackfillLevelReached(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reached := func(userID uint) int {
+		t.Helper()
+		reward, err := repo.GetUserReward(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reward.LevelReached
+	}
+	if got := reached(userID); got != 3 {
+		t.Errorf("expected level 3 to be unlocked, got %d", got)
+	}
+	if got := reached(finishedID); got != 4 {
+		t.Errorf("expected whichever level comes after 3 to be unlocked, got %d", got)
+	}
+	if _, err := repo.CompleteLevel(userID, levels[2].ID, ""); err != nil {
+		t.Errorf("expected level 3 to complete, got %v", err)
+	}
+
+	next := createLevels(t, repo, 10, 6)[0]
+	if _, err := repo.CompleteLevel(finishedID, next.ID, ""); err != nil {
+		t.Errorf("expected a level added after the last one to be playable, got %v", err)
+	}
+}
+
+func TestCompleteLevelAfterRevealPaysNothing(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	levels := createLevels(t, repo, 100, 1, 2)
+	const userID = 1
+
+	if err := repo.RecordAttempt(&levelinfra.LevelAttempt{
+		UserID:         userID,
+		LevelID:        levels[0].ID,
+		Method:         levelinfra.AttemptAnswer,
+		Answer:         "Daisy",
+		RevealedAnswer: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	revealed, err := repo.CompleteLevel(userID, levels[0].ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revealed.Reward != 0 {
+		t.Errorf("expected the revealed level to pay nothing, got %d", revealed.Reward)
+	}
+	next, err := repo.CompleteLevel(userID, levels[1].ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Reward != 100 {
+		t.Errorf("expected the next level to pay 100, got %d", next.Reward)
+	}
+	if got := balance(t, repo, userID); got != 100 {
+		t.Errorf("expected a balance of 100, got %d", got)
+	}
+}
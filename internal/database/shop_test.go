@@ -0,0 +1,70 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+// createShopItem adds an item on sale.
+func createShopItem(t *testing.T, repo *levelinfra.PlantRepository, name string, itemType levelinfra.ShopItemType, price int) levelinfra.ShopItem {
+	t.Helper()
+	item := levelinfra.ShopItem{Name: name, Type: itemType, Price: price, IsActive: true}
+	if err := repo.CreateShopItem(&item); err != nil {
+		t.Fatal(err)
+	}
+	return item
+}
+
+func TestPurchaseItemRejectsOverdraft(t *testing.T) {
+	db := newTestDB(t)
+	repo := levelinfra.NewPlantRepository(db)
+	hint := createShopItem(t, repo, "Hint", levelinfra.ShopItemHint, 30)
+	badge := createShopItem(t, repo, "Badge", levelinfra.ShopItemBadge, 50)
+	const userID = 1
+	if _, err := repo.AdjustCoins(userID, 100, "welcome", 99, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	purchase, err := repo.PurchaseItem(userID, hint.ID, 1, "tap-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purchase.Cost != 30 || purchase.Balance != 70 || purchase.Inventory.Quantity != 1 {
+		t.Errorf("unexpected purchase %+v", purchase)
+	}
+	replay, err := repo.PurchaseItem(userID, hint.ID, 1, "tap-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replay.Replayed || replay.Cost != 30 || replay.Balance != 70 || replay.Transaction.ID != purchase.Transaction.ID {
+		t.Errorf("expected the retry to replay %+v, got %+v", purchase, replay)
+	}
+	if _, err := repo.PurchaseItem(userID, badge.ID, 1, "tap-1"); !errors.Is(err, levelinfra.ErrPurchaseKeyReused) {
+		t.Errorf("expected the key to be refused for another item, got %v", err)
+	}
+	if _, err := repo.PurchaseItem(userID, hint.ID, 3, ""); !errors.Is(err, levelinfra.ErrInsufficientCoins) {
+		t.Errorf("expected 90 coins of hints to overdraw 70, got %v", err)
+	}
+	if _, err := repo.PurchaseItem(userID, hint.ID, 2, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.PurchaseItem(userID, badge.ID, 1, ""); !errors.Is(err, levelinfra.ErrInsufficientCoins) {
+		t.Errorf("expected the badge to overdraw 10, got %v", err)
+	}
+
+	if got := balance(t, repo, userID); got != 10 {
+		t.Errorf("expected a balance of 10, got %d", got)
+	}
+	if entries := coinEntries(t, db, userID); len(entries) != 3 || entries[len(entries)-1].BalanceAfter != 10 {
+		t.Errorf("expected only the credit and two purchases in the ledger, got %+v", entries)
+	}
+	inventory, err := repo.GetInventory(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inventory) != 1 || inventory[0].ShopItemID != hint.ID || inventory[0].Quantity != 3 {
+		t.Errorf("expected three hints and no badge, got %+v", inventory)
+	}
+}
@@ -1,32 +1,111 @@
-package dto
-
-
-
-type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-}
-
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
-}
-
-type GuestLoginRequest struct {
-	AndroidID string `json:"android_id" binding:"required"`
-	Username  string `json:"username" binding:"required"`
-}
-
-// Level DTOs
-type CreateLevelRequest struct {
-	Riddle    string `json:"riddle" binding:"required,min=10,max=500"`
-	PlantName string `json:"plant_name" binding:"required,min=2,max=255"`
-	Reward    int    `json:"reward" binding:"required,min=1"`
-}
-
-type UpdateLevelRequest struct {
-	Riddle    string `json:"riddle" binding:"omitempty,min=10,max=500"`
-	PlantName string `json:"plant_name" binding:"omitempty,min=2,max=255"`
-	Reward    int    `json:"reward" binding:"omitempty,min=1"`
-}
+package dto
+
+
+
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type GuestLoginRequest struct {
+	AndroidID    string `json:"android_id" binding:"required"`
+	Username     string `json:"username" binding:"required"`
+	DeviceSecret string `json:"device_secret,omitempty"`
+}
+
+type BindDeviceRequest struct {
+	AndroidID string `json:"android_id" binding:"required"`
+}
+
+type GoogleIDTokenRequest struct {
+	IDToken string `json:"id_token" binding:"required"`
+}
+
+// AppleSignInRequest carries what the app received from Sign in with Apple.
+// Apple only hands the user's name to the app on the first authorization,
+// so the app forwards it alongside the identity token.
+type AppleSignInRequest struct {
+	IdentityToken string `json:"identity_token" binding:"required"`
+	Nonce         string `json:"nonce,omitempty"` // Raw nonce whose SHA-256 the app sent to Apple
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+}
+
+type LinkEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	Username string `json:"username,omitempty"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// UpdateProfileRequest changes profile fields. Omitted fields are left alone
+// and an empty string clears an optional field.
+type UpdateProfileRequest struct {
+	Username          *string `json:"username,omitempty" binding:"omitempty,min=3,max=32"`
+	DisplayName       *string `json:"display_name,omitempty" binding:"omitempty,max=64"`
+	Bio               *string `json:"bio,omitempty" binding:"omitempty,max=280"`
+	Country           *string `json:"country,omitempty"`
+	PreferredLanguage *string `json:"preferred_language,omitempty"`
+}
+
+// DeleteAccountRequest confirms a deletion request. Password is required
+// when the account has one.
+type DeleteAccountRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app or, where
+// noted, a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorVerifyRequest completes a login that needs a second factor.
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=player content-editor admin"`
+}
+
+// Level DTOs
+type CreateLevelRequest struct {
+	Riddle    string `json:"riddle" binding:"required,min=10,max=500"`
+	PlantName string `json:"plant_name" binding:"required,min=2,max=255"`
+	Reward    int    `json:"reward" binding:"required,min=1"`
+}
+
+type UpdateLevelRequest struct {
+	Riddle    string `json:"riddle" binding:"omitempty,min=10,max=500"`
+	PlantName string `json:"plant_name" binding:"omitempty,min=2,max=255"`
+	Reward    int    `json:"reward" binding:"omitempty,min=1"`
+}
@@ -1,95 +1,139 @@
-package dto
-
-import (
-    "plantgo-backend/internal/modules/auth/infrastructure" 
-	"time"
-)
-
-type AuthResponse struct {
-	Token string    `json:"token"`
-	User  infrastructure.User `json:"user"`
-}
-
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
-
-type LevelResponse struct {
-	ID        uint      `json:"id"`
-	Riddle    string    `json:"riddle"`
-	PlantName string    `json:"plant_name"`
-	Reward    int       `json:"reward"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
-
-type LevelListResponse struct {
-	Levels []LevelResponse `json:"levels"`
-	Total  int64           `json:"total"`
-}
-
-// Game Data DTOs
-type GameLevelData struct {
-	ID          uint `json:"id"`
-	Reward      int  `json:"reward"`
-	IsCompleted bool `json:"is_completed"`
-	IsUnlocked  bool `json:"is_unlocked"`
-}
-
-type GameDataResponse struct {
-	UserReward struct {
-		ID           uint `json:"id"`
-		UserID       uint `json:"user_id"`
-		TotalRewards int  `json:"total_rewards"`
-		LevelReached int  `json:"level_reached"`
-	} `json:"user_reward"`
-	Levels          []GameLevelData `json:"levels"`
-	CompletedLevels int             `json:"completed_levels"`
-	TotalLevels     int             `json:"total_levels"`
-}
-
-type LevelDetailsResponse struct {
-	ID        uint   `json:"id"`
-	Riddle    string `json:"riddle"`
-	PlantName string `json:"plant_name"`
-	Reward    int    `json:"reward"`
-}
-
-// User Progress DTOs
-type SubmitAnswerRequest struct {
-	LevelID uint   `json:"level_id" binding:"required"`
-	Answer  string `json:"answer" binding:"required,min=1,max=255"`
-}
-
-type SubmitAnswerResponse struct {
-	IsCorrect      bool   `json:"is_correct"`
-	Message        string `json:"message"`
-	RewardGained   int    `json:"reward_gained"`
-	LevelCompleted bool   `json:"level_completed"`
-	TotalRewards   int    `json:"total_rewards"`
-	CorrectAnswer  string `json:"correct_answer,omitempty"`
-}
-
-type UserRewardResponse struct {
-	ID           uint      `json:"id"`
-	UserID       uint      `json:"user_id"`
-	TotalRewards int       `json:"total_rewards"`
-	LevelReached int       `json:"level_reached"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-}
-
-// Error DTOs
-type LevelErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code,omitempty"`
-}
-
-// Success DTOs
-type SuccessResponse struct {
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
-
+package dto
+
+import (
+    "plantgo-backend/internal/modules/auth/infrastructure" 
+	"time"
+)
+
+type AuthResponse struct {
+	Token        string              `json:"token"`
+	RefreshToken string              `json:"refresh_token,omitempty"`
+	TokenType    string              `json:"token_type,omitempty"`
+	ExpiresIn    int64               `json:"expires_in,omitempty"`
+	DeviceSecret string              `json:"device_secret,omitempty"`
+	User         infrastructure.User `json:"user"`
+}
+
+// SessionResponse describes one device the user is signed in on.
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	Platform   string    `json:"platform"`
+	AppVersion string    `json:"app_version"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	LastIP     string    `json:"last_ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	Current    bool      `json:"current"`
+}
+
+type AccountDeletionResponse struct {
+	Message             string    `json:"message"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// TwoFactorChallengeResponse is returned instead of tokens when a login
+// needs a second factor. The challenge token is exchanged at
+// /auth/2fa/verify.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+	DeviceSecret      string `json:"device_secret,omitempty"` // Set when the login also issued a device secret
+}
+
+// TOTPSetupResponse holds a pending TOTP secret. Apps show the provisioning
+// URI as a QR code.
+type TOTPSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse lists new recovery codes. They are only shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+
+type LevelResponse struct {
+	ID        uint      `json:"id"`
+	Riddle    string    `json:"riddle"`
+	PlantName string    `json:"plant_name"`
+	Reward    int       `json:"reward"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type LevelListResponse struct {
+	Levels []LevelResponse `json:"levels"`
+	Total  int64           `json:"total"`
+}
+
+// Game Data DTOs
+type GameLevelData struct {
+	ID          uint `json:"id"`
+	Reward      int  `json:"reward"`
+	IsCompleted bool `json:"is_completed"`
+	IsUnlocked  bool `json:"is_unlocked"`
+}
+
+type GameDataResponse struct {
+	UserReward struct {
+		ID           uint `json:"id"`
+		UserID       uint `json:"user_id"`
+		TotalRewards int  `json:"total_rewards"`
+		LevelReached int  `json:"level_reached"`
+	} `json:"user_reward"`
+	Levels          []GameLevelData `json:"levels"`
+	CompletedLevels int             `json:"completed_levels"`
+	TotalLevels     int             `json:"total_levels"`
+}
+
+type LevelDetailsResponse struct {
+	ID        uint   `json:"id"`
+	Riddle    string `json:"riddle"`
+	PlantName string `json:"plant_name"`
+	Reward    int    `json:"reward"`
+}
+
+// User Progress DTOs
+type SubmitAnswerRequest struct {
+	LevelID uint   `json:"level_id" binding:"required"`
+	Answer  string `json:"answer" binding:"required,min=1,max=255"`
+}
+
+type SubmitAnswerResponse struct {
+	IsCorrect      bool   `json:"is_correct"`
+	Message        string `json:"message"`
+	RewardGained   int    `json:"reward_gained"`
+	LevelCompleted bool   `json:"level_completed"`
+	TotalRewards   int    `json:"total_rewards"`
+	CorrectAnswer  string `json:"correct_answer,omitempty"`
+}
+
+type UserRewardResponse struct {
+	ID           uint      `json:"id"`
+	UserID       uint      `json:"user_id"`
+	TotalRewards int       `json:"total_rewards"`
+	LevelReached int       `json:"level_reached"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Error DTOs
+type LevelErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+// Success DTOs
+type SuccessResponse struct {
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
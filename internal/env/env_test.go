@@ -0,0 +1,61 @@
+package env
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInt(t *testing.T) {
+	cases := []struct {
+		value string
+		want  int
+	}{
+		{"", 5},
+		{"12", 12},
+		{"0", 5},
+		{"-3", 5},
+		{"many", 5},
+	}
+	for _, tc := range cases {
+		t.Setenv("TEST_INT", tc.value)
+		if got := Int("TEST_INT", 5); got != tc.want {
+			t.Errorf("Int(%q) = %d, want %d", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	cases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", time.Hour},
+		{"15m", 15 * time.Minute},
+		{"-1m", time.Hour},
+		{"soon", time.Hour},
+	}
+	for _, tc := range cases {
+		t.Setenv("TEST_DURATION", tc.value)
+		if got := Duration("TEST_DURATION", time.Hour); got != tc.want {
+			t.Errorf("Duration(%q) = %s, want %s", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestFloat(t *testing.T) {
+	cases := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0.7},
+		{"0.85", 0.85},
+		{"0", 0.7},
+		{"high", 0.7},
+	}
+	for _, tc := range cases {
+		t.Setenv("TEST_FLOAT", tc.value)
+		if got := Float("TEST_FLOAT", 0.7); got != tc.want {
+			t.Errorf("Float(%q) = %g, want %g", tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSender writes each message to its own .eml file in a directory. It is
+// handy for local development and for tests that need to read the links that
+// were sent.
+type FileSender struct {
+	dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+func (f *FileSender) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	f.seq++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().UTC().Format("20060102T150405"), f.seq)
+	f.mu.Unlock()
+
+	data, err := buildMessage("", msg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(f.dir, name), data, 0o600)
+}
@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSenderWritesMessage(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := NewFileSender(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := Message{To: "player@example.com", Subject: "Verify your email", Body: "Open https://example.com/verify?token=abc"}
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected one message, got %d", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"To: player@example.com", "Subject: Verify your email", "token=abc"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("message is missing %q", want)
+		}
+	}
+}
+
+func TestBuildMessageRejectsHeaderInjection(t *testing.T) {
+	_, err := buildMessage("noreply@example.com", Message{To: "a@example.com\r\nBcc: b@example.com", Subject: "hi"})
+	if err == nil {
+		t.Fatal("expected an error for a header with a line break")
+	}
+}
@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+const (
+	defaultAccountDeletionGrace = 30 * 24 * time.Hour
+	defaultAccountPurgeInterval = time.Hour
+	accountPurgeBatchSize       = 100
+)
+
+// accountDeletionGrace is how long a deletion request can be cancelled
+// before the account is purged.
+func accountDeletionGrace() time.Duration {
+	return env.Duration("ACCOUNT_DELETION_GRACE", defaultAccountDeletionGrace)
+}
+
+func accountPurgeInterval() time.Duration {
+	return env.Duration("ACCOUNT_PURGE_INTERVAL", defaultAccountPurgeInterval)
+}
+
+// runAccountPurger purges accounts whose deletion grace period has ended,
+// once at startup and then every interval. It never returns.
+func (s *AuthService) runAccountPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.purgeDueAccounts(time.Now().UTC())
+		<-ticker.C
+	}
+}
+
+func (s *AuthService) purgeDueAccounts(now time.Time) {
+	for {
+		users, err := s.userRepo.GetUsersDueForPurge(now, accountPurgeBatchSize)
+		if err != nil {
+			log.Printf("Failed to load accounts due for deletion: %v", err)
+			return
+		}
+		purged := 0
+		for _, user := range users {
+			if err := s.userRepo.PurgeUser(user.ID); err != nil {
+				log.Printf("Failed to purge user %d: %v", user.ID, err)
+				continue
+			}
+			s.deleteAvatar(&user)
+			purged++
+			log.Printf("Purged user %d after deletion request", user.ID)
+		}
+		// Stop when the batch was short or nothing could be purged, so a
+		// failing row is retried on the next run instead of in a tight loop.
+		if len(users) < accountPurgeBatchSize || purged == 0 {
+			return
+		}
+	}
+}
+
+// RequestAccountDeletionHandler godoc
+// @Summary      Request account deletion
+// @Description  Schedules the caller's account and all of its data for permanent deletion after a grace period (30 days by default). Accounts with a password must confirm it. Signing in and cancelling stops the deletion.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.DeleteAccountRequest false "Password confirmation"
+// @Success      202 {object} dto.AccountDeletionResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/account/deletion [post]
+func (s *AuthService) RequestAccountDeletionHandler(c *gin.Context) {
+	var req dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.PasswordHash != nil && !verifyPassword(req.Password, *user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+
+	scheduledAt := time.Now().UTC().Add(accountDeletionGrace())
+	if user.DeletionScheduledAt != nil {
+		// Asking again does not push the date back.
+		scheduledAt = *user.DeletionScheduledAt
+	} else if err := s.userRepo.ScheduleDeletion(user.ID, scheduledAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.AccountDeletionResponse{
+		Message:             "Account scheduled for deletion",
+		DeletionScheduledAt: scheduledAt,
+	})
+}
+
+// CancelAccountDeletionHandler godoc
+// @Summary      Cancel account deletion
+// @Description  Cancels a pending deletion request during its grace period
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/account/deletion [delete]
+func (s *AuthService) CancelAccountDeletionHandler(c *gin.Context) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "No deletion is pending"})
+		return
+	}
+	if err := s.userRepo.CancelDeletion(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Account deletion cancelled"})
+}
+
+// ExportAccountDataHandler godoc
+// @Summary      Download my data
+// @Description  Returns every record stored about the caller: a ZIP archive with one JSON file per kind of record, or a single JSON document with format=json
+// @Tags         Auth
+// @Produce      application/zip
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        format query string false "zip (default) or json"
+// @Success      200 {file} file
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/account/export [get]
+func (s *AuthService) ExportAccountDataHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be zip or json"})
+		return
+	}
+
+	export, err := s.userRepo.ExportUserData(userID)
+	if err != nil {
+		log.Printf("Failed to export data for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account data"})
+		return
+	}
+
+	filename := fmt.Sprintf("plantgo-user-%d-%s", userID, export.ExportedAt.Format("20060102"))
+	c.Header("Cache-Control", "no-store")
+	if format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := writeExportArchive(c.Writer, export); err != nil {
+		// Headers are already sent, so all that is left is to log it.
+		log.Printf("Failed to write data export for user %d: %v", userID, err)
+	}
+}
+
+// writeExportArchive writes export as a ZIP with one JSON file per section,
+// named after its JSON key. The sections are read from UserDataExport, so a
+// table added there is archived too.
+func writeExportArchive(w io.Writer, export *infrastructure.UserDataExport) error {
+	type section struct {
+		name string
+		data interface{}
+	}
+	var files []section
+	value := reflect.ValueOf(export).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		key, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" || key == "exported_at" {
+			continue
+		}
+		files = append(files, section{key + ".json", value.Field(i).Interface()})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestWriteExportArchive(t *testing.T) {
+	export := &infrastructure.UserDataExport{
+		ExportedAt:       time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		User:             infrastructure.User{ID: 7, Username: "fern"},
+		LevelAttempts:    []levelinfra.LevelAttempt{{ID: 3, UserID: 7, Answer: "fern"}},
+		Rewards:          []levelinfra.UserReward{{ID: 1, UserID: 7, TotalRewards: 250}},
+		CoinTransactions: []levelinfra.CoinTransaction{{ID: 4, UserID: 7, Amount: 250}},
+		Inventory:        []levelinfra.UserInventoryItem{{ID: 5, UserID: 7, Quantity: 2}},
+		HintUnlocks:      []levelinfra.UserHintUnlock{{ID: 6, UserID: 7, CoinsSpent: 10}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeExportArchive(&buf, export); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// Every section of the JSON export must have its file in the archive.
+	sections, err := json.Marshal(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(sections, &keys); err != nil {
+		t.Fatal(err)
+	}
+	delete(keys, "exported_at")
+	for key := range keys {
+		if _, ok := files[key+".json"]; !ok {
+			t.Errorf("%s.json missing from archive", key)
+		}
+	}
+	if len(files) != len(keys) {
+		t.Errorf("expected %d files, got %d", len(keys), len(files))
+	}
+
+	readSection := func(name string, dest interface{}) {
+		t.Helper()
+		f, ok := files[name]
+		if !ok {
+			t.Fatalf("%s missing from archive", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		if err := json.NewDecoder(rc).Decode(dest); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	var rewards []levelinfra.UserReward
+	readSection("rewards.json", &rewards)
+	if len(rewards) != 1 || rewards[0].TotalRewards != 250 {
+		t.Errorf("unexpected rewards: %+v", rewards)
+	}
+	var attempts []levelinfra.LevelAttempt
+	readSection("level_attempts.json", &attempts)
+	if len(attempts) != 1 || attempts[0].Answer != "fern" {
+		t.Errorf("unexpected level attempts: %+v", attempts)
+	}
+	var transactions []levelinfra.CoinTransaction
+	readSection("coin_transactions.json", &transactions)
+	if len(transactions) != 1 || transactions[0].Amount != 250 {
+		t.Errorf("unexpected coin transactions: %+v", transactions)
+	}
+	var inventory []levelinfra.UserInventoryItem
+	readSection("inventory.json", &inventory)
+	if len(inventory) != 1 || inventory[0].Quantity != 2 {
+		t.Errorf("unexpected inventory: %+v", inventory)
+	}
+	var unlocks []levelinfra.UserHintUnlock
+	readSection("hint_unlocks.json", &unlocks)
+	if len(unlocks) != 1 || unlocks[0].CoinsSpent != 10 {
+		t.Errorf("unexpected hint unlocks: %+v", unlocks)
+	}
+}
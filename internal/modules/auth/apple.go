@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+const (
+	appleJWKSURL = "https://appleid.apple.com/auth/keys"
+	appleIssuer  = "https://appleid.apple.com"
+	// appleRelayDomain hosts the forwarding addresses of users who chose
+	// Hide My Email.
+	appleRelayDomain = "@privaterelay.appleid.com"
+)
+
+// newAppleIDTokenVerifier accepts identity tokens minted for the app bundle
+// IDs and Services IDs listed in APPLE_CLIENT_IDS.
+func newAppleIDTokenVerifier(keys KeySource) *idTokenVerifier {
+	return &idTokenVerifier{
+		keys:      keys,
+		issuers:   []string{appleIssuer},
+		audiences: splitEnvList(os.Getenv("APPLE_CLIENT_IDS")),
+	}
+}
+
+// appleNonceMatches checks the token's nonce claim against the raw nonce the
+// app kept. Apps send Apple the hex SHA-256 of the nonce, so a stolen token
+// cannot be replayed without it. Tokens without a nonce are only accepted
+// when the app sent none.
+func appleNonceMatches(claim, raw string) bool {
+	if claim == "" || raw == "" {
+		return claim == raw
+	}
+	sum := sha256.Sum256([]byte(raw))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(claim)), []byte(expected)) == 1
+}
+
+// isApplePrivateRelay reports whether the token's email is a Hide My Email
+// forwarding address. Those still deliver mail but say nothing about the
+// user, so they are never used as a username.
+func isApplePrivateRelay(claims *idTokenClaims) bool {
+	return bool(claims.IsPrivateEmail) || strings.HasSuffix(strings.ToLower(claims.Email), appleRelayDomain)
+}
+
+// appleUsername picks the username for a new account from the name the app
+// forwarded, else the local part of a real email, else defaultUsername.
+func appleUsername(name string, claims *idTokenClaims) string {
+	if name == "" && claims.Email != "" && !isApplePrivateRelay(claims) {
+		name = strings.Split(claims.Email, "@")[0]
+	}
+	return deriveUsername(name)
+}
+
+// appleFullName joins the name parts the app received on first authorization.
+func appleFullName(req *dto.AppleSignInRequest) string {
+	name := strings.Join(strings.Fields(req.GivenName+" "+req.FamilyName), " ")
+	return truncate(name, 64)
+}
+
+// saveAppleUser signs in the PlantGo account for a verified Apple identity,
+// creating it if needed. Apple only shares the user's name the first time
+// they authorize the app, so it is kept whenever it arrives and the account
+// has none yet.
+func (s *AuthService) saveAppleUser(claims *idTokenClaims, name string) (*infrastructure.User, error) {
+	identity := &infrastructure.UserIdentity{
+		Provider:      infrastructure.ProviderApple,
+		Subject:       claims.Subject,
+		EmailVerified: bool(claims.EmailVerified),
+	}
+	user := &infrastructure.User{
+		Username:    appleUsername(name, claims),
+		DisplayName: name,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if claims.Email != "" {
+		email := claims.Email
+		identity.Email = &email
+		user.Email = &email
+		if identity.EmailVerified {
+			verifiedAt := time.Now().UTC()
+			user.EmailVerifiedAt = &verifiedAt
+		}
+	}
+	user.Role = roleForNewUser(user)
+
+	saved, err := s.userRepo.SignInWithIdentity(identity, user)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" && saved.DisplayName == "" {
+		saved.DisplayName = name
+		if err := s.userRepo.UpdateUser(saved); err != nil {
+			log.Printf("Failed to store Apple name for user %d: %v", saved.ID, err)
+		}
+	}
+	return saved, nil
+}
+
+// verifyAppleRequest checks the identity token and nonce, writing an error
+// response and returning false if either is bad.
+func (s *AuthService) verifyAppleRequest(c *gin.Context, req *dto.AppleSignInRequest) (*idTokenClaims, bool) {
+	claims, err := s.appleIDTokens.verify(c.Request.Context(), req.IdentityToken)
+	if err != nil {
+		log.Printf("Rejected Apple identity token: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Apple identity token"})
+		return nil, false
+	}
+	if !appleNonceMatches(claims.Nonce, req.Nonce) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Apple identity token"})
+		return nil, false
+	}
+	return claims, true
+}
+
+// AppleSignInHandler godoc
+// @Summary      Sign in with Apple
+// @Description  For the iOS app. Verifies the identity token against Apple's keys, checks issuer, audience, expiry and nonce, then returns a PlantGo JWT. An account that already uses the verified email is linked instead of duplicated. Send the name Apple returned on the first authorization; it is not in the token.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.AppleSignInRequest true "Apple identity token"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/apple/token [post]
+func (s *AuthService) AppleSignInHandler(c *gin.Context) {
+	var req dto.AppleSignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := s.verifyAppleRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	savedUser, err := s.saveAppleUser(claims, appleFullName(&req))
+	if err != nil {
+		identitySignInFailed(c, err)
+		return
+	}
+
+	s.respondWithLogin(c, savedUser)
+}
+
+// LinkAppleHandler godoc
+// @Summary      Link an Apple account
+// @Description  Attaches the Apple ID from a verified identity token to the caller's account. If that Apple ID already has a PlantGo account, a guest caller is merged into it. Accounts with two-factor login get a dto.TwoFactorChallengeResponse instead of tokens, and the merge waits until it is completed at /auth/2fa/verify.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.AppleSignInRequest true "Apple identity token"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/link/apple [post]
+func (s *AuthService) LinkAppleHandler(c *gin.Context) {
+	var req dto.AppleSignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, ok := s.verifyAppleRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	identity := &infrastructure.UserIdentity{
+		Provider:      infrastructure.ProviderApple,
+		Subject:       claims.Subject,
+		EmailVerified: bool(claims.EmailVerified),
+	}
+	if claims.Email != "" {
+		email := claims.Email
+		identity.Email = &email
+	}
+	s.linkExternalIdentity(c, identity, "Apple")
+}
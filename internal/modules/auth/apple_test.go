@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"plantgo-backend/internal/dto"
+)
+
+func appleTestClaims(nonce string) jwt.MapClaims {
+	sum := sha256.Sum256([]byte(nonce))
+	return jwt.MapClaims{
+		"iss": appleIssuer,
+		"aud": "com.plantgo.app",
+		"sub": "001234.abcdef",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+		// Apple sends these booleans as strings.
+		"email":            "x7k2p9@privaterelay.appleid.com",
+		"email_verified":   "true",
+		"is_private_email": "true",
+		"nonce":            hex.EncodeToString(sum[:]),
+	}
+}
+
+func TestAppleIDTokenVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APPLE_CLIENT_IDS", "com.plantgo.app, com.plantgo.web")
+	v := newAppleIDTokenVerifier(StaticKeySource{"apple-1": &key.PublicKey})
+
+	claims, err := v.verify(context.Background(), signIDToken(t, key, "apple-1", appleTestClaims("n0nce")))
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if claims.Subject != "001234.abcdef" || !bool(claims.EmailVerified) || !isApplePrivateRelay(claims) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if !appleNonceMatches(claims.Nonce, "n0nce") {
+		t.Error("expected the raw nonce to match its hash")
+	}
+
+	wrongAudience := appleTestClaims("n0nce")
+	wrongAudience["aud"] = "com.someone.else"
+	if _, err := v.verify(context.Background(), signIDToken(t, key, "apple-1", wrongAudience)); err == nil {
+		t.Error("expected a token for another app to be rejected")
+	}
+}
+
+func TestAppleNonceMatches(t *testing.T) {
+	sum := sha256.Sum256([]byte("raw"))
+	hashed := hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		claim, raw string
+		want       bool
+	}{
+		{hashed, "raw", true},
+		{strings.ToUpper(hashed), "raw", true},
+		{hashed, "other", false},
+		{hashed, "", false},
+		{"", "raw", false},
+		{"", "", true},
+		{"raw", "raw", false},
+	}
+	for _, tc := range cases {
+		if got := appleNonceMatches(tc.claim, tc.raw); got != tc.want {
+			t.Errorf("appleNonceMatches(%q, %q) = %v, want %v", tc.claim, tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestAppleUsername(t *testing.T) {
+	relay := &idTokenClaims{Email: "x7k2p9@privaterelay.appleid.com"}
+	personal := &idTokenClaims{Email: "fern@example.com"}
+
+	cases := []struct {
+		name   string
+		claims *idTokenClaims
+		want   string
+	}{
+		{"Fern Gully", relay, "Fern_Gully"},
+		{"", personal, "fern"},
+		{"", relay, defaultUsername},
+		{"", &idTokenClaims{}, defaultUsername},
+	}
+	for _, tc := range cases {
+		if got := appleUsername(tc.name, tc.claims); got != tc.want {
+			t.Errorf("appleUsername(%q, %q) = %q, want %q", tc.name, tc.claims.Email, got, tc.want)
+		}
+	}
+
+	name := appleFullName(&dto.AppleSignInRequest{GivenName: "  Fern ", FamilyName: "Gully "})
+	if name != "Fern Gully" {
+		t.Errorf("appleFullName = %q", name)
+	}
+}
+
+func TestAppleSignInRejectsBadTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APPLE_CLIENT_IDS", "com.plantgo.app")
+	s := newTestAuthService()
+	s.appleIDTokens = newAppleIDTokenVerifier(StaticKeySource{"apple-1": &key.PublicKey})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/auth/apple/token", s.AppleSignInHandler)
+
+	token := signIDToken(t, key, "apple-1", appleTestClaims("n0nce"))
+	cases := map[string]string{
+		"garbage":     `{"identity_token":"not-a-jwt","nonce":"n0nce"}`,
+		"wrong nonce": `{"identity_token":"` + token + `","nonce":"replayed"}`,
+		"no nonce":    `{"identity_token":"` + token + `"}`,
+	}
+	for name, body := range cases {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/auth/apple/token", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rr.Code)
+		}
+	}
+}
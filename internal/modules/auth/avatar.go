@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+const (
+	// avatarSize is the width and height avatars are stored at.
+	avatarSize = 256
+	// maxAvatarUploadBytes caps the uploaded file; maxAvatarPixels caps the
+	// decoded image so a small, highly compressed file cannot exhaust memory.
+	maxAvatarUploadBytes = 5 << 20
+	maxAvatarPixels      = 40_000_000
+	avatarStoreTimeout   = 30 * time.Second
+)
+
+var errAvatarInvalid = errors.New("avatar must be a PNG, JPEG or GIF image")
+
+// processAvatar decodes an uploaded image, crops it to a centred square and
+// scales it to avatarSize, returning the result as PNG.
+func processAvatar(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxAvatarUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAvatarUploadBytes {
+		return nil, fmt.Errorf("avatar must be at most %d MB", maxAvatarUploadBytes>>20)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errAvatarInvalid
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, errors.New("avatar dimensions are too large")
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errAvatarInvalid
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, resizeSquare(src, avatarSize)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resizeSquare crops the centred square of src and scales it to size x size,
+// averaging the source pixels that fall into each destination pixel.
+func resizeSquare(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0 := y0 + y*side/size
+		sy1 := y0 + (y+1)*side/size
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < size; x++ {
+			sx0 := x0 + x*side/size
+			sx1 := x0 + (x+1)*side/size
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// deleteAvatar removes the user's stored avatar file, if any. Failures are
+// logged; a stray file is harmless.
+func (s *AuthService) deleteAvatar(user *infrastructure.User) {
+	if user.AvatarKey == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), avatarStoreTimeout)
+	defer cancel()
+	if err := s.avatars.Delete(ctx, user.AvatarKey); err != nil {
+		log.Printf("Failed to delete avatar %s for user %d: %v", user.AvatarKey, user.ID, err)
+	}
+}
+
+// UploadAvatarHandler godoc
+// @Summary      Upload my avatar
+// @Description  Replaces the caller's avatar. The image is cropped to a square and resized to 256x256. PNG, JPEG and GIF up to 5 MB are accepted.
+// @Tags         Profile
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        avatar formData file true "Avatar image"
+// @Success      200 {object} infrastructure.User
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /me/avatar [post]
+func (s *AuthService) UploadAvatarHandler(c *gin.Context) {
+	// Leave room for the multipart framing around the file.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAvatarUploadBytes+(1<<20))
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar file is required"})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read avatar"})
+		return
+	}
+	defer f.Close()
+	data, err := processAvatar(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A fresh key per upload lets clients and CDNs cache avatars forever.
+	suffix, err := randomToken(9)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store avatar"})
+		return
+	}
+	key := fmt.Sprintf("avatars/%d/%s.png", user.ID, suffix)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), avatarStoreTimeout)
+	defer cancel()
+	url, err := s.avatars.Put(ctx, key, data, "image/png")
+	if err != nil {
+		log.Printf("Failed to store avatar for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store avatar"})
+		return
+	}
+
+	previous := *user
+	user.AvatarKey = key
+	user.AvatarURL = url
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		_ = s.avatars.Delete(ctx, key)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+	s.deleteAvatar(&previous)
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// DeleteAvatarHandler godoc
+// @Summary      Remove my avatar
+// @Tags         Profile
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} infrastructure.User
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /me/avatar [delete]
+func (s *AuthService) DeleteAvatarHandler(c *gin.Context) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+
+	previous := *user
+	user.AvatarKey = ""
+	user.AvatarURL = ""
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+	s.deleteAvatar(&previous)
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestProcessAvatarCropsAndResizes(t *testing.T) {
+	// A wide image: red on the left third, blue in the middle, green on the right.
+	src := image.NewRGBA(image.Rect(0, 0, 900, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 900; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x < 300 {
+				c = color.RGBA{R: 255, A: 255}
+			} else if x >= 600 {
+				c = color.RGBA{G: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	var in bytes.Buffer
+	if err := jpeg.Encode(&in, src, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := processAvatar(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != avatarSize || b.Dy() != avatarSize {
+		t.Fatalf("expected %dx%d, got %v", avatarSize, avatarSize, b)
+	}
+	// Only the blue centre square should be left.
+	r, g, b, _ := img.At(avatarSize/2, avatarSize/2).RGBA()
+	if b>>8 < 200 || r>>8 > 50 || g>>8 > 50 {
+		t.Errorf("expected the blue centre, got %d,%d,%d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestProcessAvatarRejectsNonImages(t *testing.T) {
+	if _, err := processAvatar(strings.NewReader("not an image")); err != errAvatarInvalid {
+		t.Errorf("expected errAvatarInvalid, got %v", err)
+	}
+}
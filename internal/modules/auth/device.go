@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// newDeviceSecret returns a random secret for a guest device and the hash
+// stored on the user. The secret is high entropy, so a plain SHA-256 is
+// enough.
+func newDeviceSecret() (secret, hash string, err error) {
+	secret, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, hashToken(secret), nil
+}
+
+func deviceSecretMatches(user *infrastructure.User, presented string) bool {
+	if user.DeviceSecretHash == nil || presented == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(*user.DeviceSecretHash), []byte(hashToken(presented))) == 1
+}
+
+// respondWithDeviceSecret writes the usual login response plus a newly
+// issued device secret, if any. Accounts with two-factor login enabled get
+// a challenge instead of tokens, as for any other login.
+func (s *AuthService) respondWithDeviceSecret(c *gin.Context, user *infrastructure.User, deviceSecret string) {
+	if user.TwoFactorEnabled() {
+		s.respondWithChallenge(c, user, 0, deviceSecret)
+		return
+	}
+	response, err := s.authResponse(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
+		return
+	}
+	response.DeviceSecret = deviceSecret
+	c.JSON(http.StatusOK, response)
+}
+
+// BindDeviceHandler godoc
+// @Summary      Bind this device to the account
+// @Description  Recovery after a reinstall: once signed in with email or Google, binds the Android ID to the caller's account and issues a new device secret for guest login. Any previous device secret stops working. With two-factor login enabled, the new secret comes with a dto.TwoFactorChallengeResponse instead of tokens.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.BindDeviceRequest true "Device to bind"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/device/bind [post]
+func (s *AuthService) BindDeviceHandler(c *gin.Context) {
+	var req dto.BindDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	// A guest token only proves possession of the old secret, which is
+	// exactly what a reinstall loses, so recovery needs a linked identity.
+	if user.IsGuest() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Link an email or Google account before recovering a device"})
+		return
+	}
+	if owner, err := s.userRepo.GetUserByIdentity(infrastructure.ProviderAndroid, req.AndroidID); err == nil && owner.ID != user.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Device is bound to another account"})
+		return
+	}
+
+	secret, hash, err := newDeviceSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create device secret"})
+		return
+	}
+	device := &infrastructure.UserIdentity{
+		UserID:   user.ID,
+		Provider: infrastructure.ProviderAndroid,
+		Subject:  req.AndroidID,
+	}
+	if err := s.userRepo.LinkIdentity(device); err != nil {
+		if errors.Is(err, infrastructure.ErrIdentityTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Device is bound to another account"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bind device"})
+		return
+	}
+	user.DeviceSecretHash = &hash
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bind device"})
+		return
+	}
+	if user.Identities, err = s.userRepo.GetUserIdentities(user.ID); err != nil {
+		log.Printf("Failed to reload identities for user %d: %v", user.ID, err)
+	}
+
+	s.recordLoginSuccess(req.AndroidID)
+	s.respondWithDeviceSecret(c, user, secret)
+}
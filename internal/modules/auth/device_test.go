@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func TestDeviceSecretMatches(t *testing.T) {
+	secret, hash, err := newDeviceSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret == hash {
+		t.Fatal("the stored hash must differ from the secret")
+	}
+
+	user := &infrastructure.User{DeviceSecretHash: &hash}
+	if !deviceSecretMatches(user, secret) {
+		t.Error("expected the issued secret to match")
+	}
+	if deviceSecretMatches(user, "") || deviceSecretMatches(user, hash) || deviceSecretMatches(user, secret+"x") {
+		t.Error("expected other values to be rejected")
+	}
+	if deviceSecretMatches(&infrastructure.User{}, secret) {
+		t.Error("a user without a bound device must not match")
+	}
+}
+
+func TestRespondWithDeviceSecretChallengesTwoFactorAccounts(t *testing.T) {
+	s := newTestAuthService()
+	gin.SetMode(gin.TestMode)
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+
+	s.respondWithDeviceSecret(c, twoFactorUser(42), "new-secret")
+
+	challenge := decodeChallenge(t, rr)
+	if challenge.DeviceSecret != "new-secret" {
+		t.Errorf("expected the new device secret with the challenge, got %q", challenge.DeviceSecret)
+	}
+	if userID, guestID, err := s.parseTwoFactorChallenge(challenge.ChallengeToken); err != nil || userID != 42 || guestID != 0 {
+		t.Errorf("expected a plain challenge for user 42, got %d %d %v", userID, guestID, err)
+	}
+}
@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/mail"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+const (
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultPasswordResetTTL     = time.Hour
+	defaultAppBaseURL           = "http://localhost:5173"
+	mailSendTimeout             = time.Minute
+)
+
+func emailVerificationTTL() time.Duration {
+	return env.Duration("EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL)
+}
+
+func passwordResetTTL() time.Duration {
+	return env.Duration("PASSWORD_RESET_TTL", defaultPasswordResetTTL)
+}
+
+// appLink builds a link into the client app, e.g. APP_BASE_URL/reset-password?token=...
+func appLink(path, token string) string {
+	base := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+	if base == "" {
+		base = defaultAppBaseURL
+	}
+	return base + path + "?token=" + url.QueryEscape(token)
+}
+
+// issueUserToken creates a single-use token for the user's current email and
+// returns the raw value to put in the link. Only its hash is stored.
+func (s *AuthService) issueUserToken(user *infrastructure.User, purpose infrastructure.TokenPurpose, ttl time.Duration) (string, error) {
+	if user.Email == nil || *user.Email == "" {
+		return "", errors.New("user has no email address")
+	}
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	token := &infrastructure.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     *user.Email,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+	if err := s.userRepo.CreateUserToken(token); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// sendMail delivers msg in the background so request latency does not depend
+// on the mail server, and so responses do not reveal whether mail was sent.
+func (s *AuthService) sendMail(msg mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+func (s *AuthService) sendVerificationEmail(user *infrastructure.User) error {
+	raw, err := s.issueUserToken(user, infrastructure.TokenPurposeEmailVerification, emailVerificationTTL())
+	if err != nil {
+		return err
+	}
+	s.sendMail(mail.Message{
+		To:      *user.Email,
+		Subject: "Verify your PlantGo email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, appLink("/verify-email", raw), emailVerificationTTL()),
+	})
+	return nil
+}
+
+func (s *AuthService) sendPasswordResetEmail(user *infrastructure.User) error {
+	raw, err := s.issueUserToken(user, infrastructure.TokenPurposePasswordReset, passwordResetTTL())
+	if err != nil {
+		return err
+	}
+	s.sendMail(mail.Message{
+		To:      *user.Email,
+		Subject: "Reset your PlantGo password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your PlantGo password. If it was you, open this link:\n\n%s\n\nThe link expires in %s. If you did not ask for this, you can ignore this email.\n",
+			user.Username, appLink("/reset-password", raw), passwordResetTTL()),
+	})
+	return nil
+}
+
+// RequestEmailVerificationHandler godoc
+// @Summary      Resend the email verification link
+// @Description  Mails a new verification link to the caller's email address. Earlier links stop working.
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      202 {object} dto.SuccessResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/email/verify/request [post]
+func (s *AuthService) RequestEmailVerificationHandler(c *gin.Context) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.Email == nil || *user.Email == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account has no email address"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+		return
+	}
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to issue verification token for user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusAccepted, dto.SuccessResponse{Message: "Verification email sent"})
+}
+
+// VerifyEmailHandler godoc
+// @Summary      Verify an email address
+// @Description  Redeems the token from a verification email
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.VerifyEmailRequest true "Verification token"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/email/verify [post]
+func (s *AuthService) VerifyEmailHandler(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := s.userRepo.ConsumeUserToken(hashToken(req.Token), infrastructure.TokenPurposeEmailVerification)
+	if errors.Is(err, infrastructure.ErrUserTokenInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	verified, err := s.userRepo.MarkEmailVerified(token.UserID, token.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+	if !verified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification link is invalid or has expired"})
+		return
+	}
+	if user, err := s.userRepo.GetUserByID(token.UserID); err == nil {
+		s.promoteBootstrapAdmin(user)
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Email verified"})
+}
+
+// ForgotPasswordHandler godoc
+// @Summary      Request a password reset
+// @Description  Mails a password reset link if an account uses the email. The response is the same whether or not it does.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ForgotPasswordRequest true "Account email"
+// @Success      202 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Router       /auth/password/forgot [post]
+func (s *AuthService) ForgotPasswordHandler(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user, err := s.userRepo.GetUserByEmail(req.Email); err == nil {
+		if err := s.sendPasswordResetEmail(user); err != nil {
+			log.Printf("Failed to issue password reset token for user %d: %v", user.ID, err)
+		}
+	}
+	c.JSON(http.StatusAccepted, dto.SuccessResponse{Message: "If an account uses this email, a reset link has been sent"})
+}
+
+// ResetPasswordHandler godoc
+// @Summary      Reset a password
+// @Description  Redeems the token from a reset email, sets the new password and signs out every session
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/password/reset [post]
+func (s *AuthService) ResetPasswordHandler(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The password is checked before the link is redeemed so a rejected
+	// password does not use it up.
+	pending, err := s.userRepo.GetUserToken(hashToken(req.Token), infrastructure.TokenPurposePasswordReset)
+	if errors.Is(err, infrastructure.ErrUserTokenInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	var username string
+	if user, err := s.userRepo.GetUserByID(pending.UserID); err == nil {
+		username = user.Username
+	}
+	if err := s.passwords.validate(req.NewPassword, username, pending.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := s.userRepo.ConsumeUserToken(hashToken(req.Token), infrastructure.TokenPurposePasswordReset)
+	if errors.Is(err, infrastructure.ErrUserTokenInvalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if err := s.userRepo.UpdatePassword(token.UserID, hashedPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	if err := s.userRepo.RevokeUserRefreshTokens(token.UserID); err != nil {
+		log.Printf("Failed to revoke sessions for user %d after password reset: %v", token.UserID, err)
+	}
+	// The reset link reached the inbox, which proves the address as well.
+	if _, err := s.userRepo.MarkEmailVerified(token.UserID, token.Email); err != nil {
+		log.Printf("Failed to mark email verified for user %d: %v", token.UserID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Password has been reset"})
+}
@@ -0,0 +1,15 @@
+package auth
+
+import "testing"
+
+func TestAppLink(t *testing.T) {
+	t.Setenv("APP_BASE_URL", "https://play.plantgo.app/")
+	if got, want := appLink("/reset-password", "a+b/c"), "https://play.plantgo.app/reset-password?token=a%2Bb%2Fc"; got != want {
+		t.Errorf("appLink() = %q, want %q", got, want)
+	}
+
+	t.Setenv("APP_BASE_URL", "")
+	if got, want := appLink("/verify-email", "abc"), defaultAppBaseURL+"/verify-email?token=abc"; got != want {
+		t.Errorf("appLink() = %q, want %q", got, want)
+	}
+}
@@ -1,18 +1,154 @@
-package auth
-
-import (
-    "golang.org/x/oauth2"
-    "golang.org/x/oauth2/google"
-    "os"
-)
-
-var GoogleOAuthConfig = &oauth2.Config{
-    RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
-    ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-    ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-    Scopes: []string{
-        "https://www.googleapis.com/auth/userinfo.email",
-        "https://www.googleapis.com/auth/userinfo.profile",
-    },
-    Endpoint: google.Endpoint,
-}
\ No newline at end of file
+package auth
+
+import (
+    "context"
+    "crypto/subtle"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/oauth2"
+    "golang.org/x/oauth2/google"
+
+    "plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+var GoogleOAuthConfig = &oauth2.Config{
+    RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
+    ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
+    ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+    Scopes: []string{
+        "https://www.googleapis.com/auth/userinfo.email",
+        "https://www.googleapis.com/auth/userinfo.profile",
+    },
+    Endpoint: google.Endpoint,
+}
+
+const (
+    googleUserInfoURL   = "https://www.googleapis.com/oauth2/v2/userinfo"
+    oauthStateCookie    = "plantgo_oauth_state"
+    oauthVerifierCookie = "plantgo_oauth_verifier"
+    oauthCookieTTL      = 10 * time.Minute
+    googleJWKSURL       = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+// googleIDTokenIssuers are the issuers Google uses in its ID tokens.
+var googleIDTokenIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+// newGoogleIDTokenVerifier accepts ID tokens minted for the web client and
+// for any extra client IDs (e.g. the Android and iOS apps) listed in
+// GOOGLE_ID_TOKEN_AUDIENCES.
+func newGoogleIDTokenVerifier(keys KeySource) *idTokenVerifier {
+    audiences := splitEnvList(os.Getenv("GOOGLE_ID_TOKEN_AUDIENCES"))
+    if GoogleOAuthConfig.ClientID != "" {
+        audiences = append(audiences, GoogleOAuthConfig.ClientID)
+    }
+    return &idTokenVerifier{keys: keys, issuers: googleIDTokenIssuers, audiences: audiences}
+}
+
+// googleUserInfo is the subset of the userinfo response we rely on.
+type googleUserInfo struct {
+    ID            string `json:"id"`
+    Email         string `json:"email"`
+    VerifiedEmail bool   `json:"verified_email"`
+    Name          string `json:"name"`
+}
+
+func (u googleUserInfo) displayName() string {
+    if strings.TrimSpace(u.Name) != "" {
+        return u.Name
+    }
+    return strings.Split(u.Email, "@")[0]
+}
+
+func fetchGoogleUserInfo(ctx context.Context, token *oauth2.Token) (*googleUserInfo, error) {
+    client := GoogleOAuthConfig.Client(ctx, token)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return nil, fmt.Errorf("userinfo returned %d: %s", resp.StatusCode, body)
+    }
+
+    var info googleUserInfo
+    if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+        return nil, fmt.Errorf("failed to decode userinfo: %w", err)
+    }
+    if info.ID == "" || info.Email == "" {
+        return nil, fmt.Errorf("userinfo response is missing id or email")
+    }
+    return &info, nil
+}
+
+// setOAuthCookies binds the OAuth state and PKCE verifier to the browser that
+// started the login, so a callback cannot be replayed from another browser.
+func setOAuthCookies(c *gin.Context, state, verifier string) {
+    c.SetSameSite(http.SameSiteLaxMode)
+    maxAge := int(oauthCookieTTL.Seconds())
+    c.SetCookie(oauthStateCookie, state, maxAge, "/", "", isSecureRequest(c), true)
+    c.SetCookie(oauthVerifierCookie, verifier, maxAge, "/", "", isSecureRequest(c), true)
+}
+
+func readOAuthCookies(c *gin.Context) (state, verifier string, ok bool) {
+    state, stateErr := c.Cookie(oauthStateCookie)
+    verifier, verifierErr := c.Cookie(oauthVerifierCookie)
+    if stateErr != nil || verifierErr != nil || state == "" || verifier == "" {
+        return "", "", false
+    }
+    return state, verifier, true
+}
+
+func clearOAuthCookies(c *gin.Context) {
+    c.SetSameSite(http.SameSiteLaxMode)
+    c.SetCookie(oauthStateCookie, "", -1, "/", "", isSecureRequest(c), true)
+    c.SetCookie(oauthVerifierCookie, "", -1, "/", "", isSecureRequest(c), true)
+}
+
+func stateMatches(expected, actual string) bool {
+    return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
+
+func isSecureRequest(c *gin.Context) bool {
+    return c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+}
+
+// saveGoogleUser signs in the PlantGo account for a verified Google
+// identity, creating it if needed. Both the web OAuth flow and native
+// ID-token sign-in end here.
+func (s *AuthService) saveGoogleUser(info *googleUserInfo) (*infrastructure.User, error) {
+    email := info.Email
+    identity := &infrastructure.UserIdentity{
+        Provider:      infrastructure.ProviderGoogle,
+        Subject:       info.ID,
+        Email:         &email,
+        EmailVerified: info.VerifiedEmail,
+    }
+
+    user := &infrastructure.User{
+        Email:        &email,
+        Username:     deriveUsername(info.displayName()),
+        PasswordHash: nil, // Google users don't have password
+        CreatedAt:    time.Now().UTC(),
+        UpdatedAt:    time.Now().UTC(),
+    }
+    // Only verified Google emails get this far.
+    if info.VerifiedEmail {
+        verifiedAt := time.Now().UTC()
+        user.EmailVerifiedAt = &verifiedAt
+    }
+    user.Role = roleForNewUser(user)
+    return s.userRepo.SignInWithIdentity(identity, user)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGoogleLoginSetsStateAndPKCE(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	s := newTestAuthService()
+	r.GET("/auth/google/login", s.GoogleLoginHandler)
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/auth/google/login", nil)
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected redirect, got %d", rr.Code)
+	}
+	location, err := url.Parse(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := location.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Errorf("expected a PKCE challenge, got %q", location.RawQuery)
+	}
+
+	var stateCookie *http.Cookie
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == oauthStateCookie {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil || !stateCookie.HttpOnly {
+		t.Fatal("expected an HttpOnly state cookie")
+	}
+	if query.Get("state") != stateCookie.Value || len(stateCookie.Value) < 32 {
+		t.Errorf("state %q does not match cookie %q", query.Get("state"), stateCookie.Value)
+	}
+}
+
+func TestGoogleCallbackRejectsBadState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	s := newTestAuthService()
+	r.GET("/auth/google/callback", s.GoogleCallbackHandler)
+
+	cases := map[string]struct {
+		query   string
+		cookies bool
+	}{
+		"no cookies":     {"state=abc&code=xyz", false},
+		"state mismatch": {"state=other&code=xyz", true},
+		"consent denied": {"error=access_denied&state=abc", true},
+		"missing code":   {"state=abc", true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/auth/google/callback?"+tc.query, nil)
+			if tc.cookies {
+				req.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: "abc"})
+				req.AddCookie(&http.Cookie{Name: oauthVerifierCookie, Value: "verifier"})
+			}
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Header().Get("Set-Cookie"), oauthStateCookie+"=;") {
+				t.Error("expected the state cookie to be cleared")
+			}
+		})
+	}
+}
@@ -1,320 +1,436 @@
-package auth
-
-import (
-	"context"
-	"encoding/json"
-	"io"
-	"net/http"
-	"os"
-	"strconv"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"gorm.io/gorm"
-	"golang.org/x/crypto/bcrypt"
-
-    "plantgo-backend/internal/dto"
-    "plantgo-backend/internal/modules/auth/infrastructure"
-)
-
-type AuthService struct {
-	userRepo *infrastructure.UserRepository
-}
-
-func NewAuthService(db *gorm.DB) *AuthService {
-	return &AuthService{
-		userRepo: infrastructure.NewUserRepository(db),
-	}
-}
-
-// GuestLoginHandler godoc
-// @Summary      Guest login
-// @Description  Authenticates or creates a guest user using Android ID and username
-// @Tags         Auth
-// @Accept       json
-// @Produce      json
-// @Param        request body dto.GuestLoginRequest true "Guest login credentials"
-// @Success      200 {object} dto.AuthResponse
-// @Failure      400 {object} dto.ErrorResponse
-// @Failure      500 {object} dto.ErrorResponse
-// @Router       /auth/guest/login [post]
-func (s *AuthService) GuestLoginHandler(c *gin.Context) {
-	var req dto.GuestLoginRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
-		return
-	}
-
-	user, exists := s.userRepo.UserExists("", req.AndroidID)
-	if exists {
-		if user.Username != req.Username {
-			user.Username = req.Username
-			user.UpdatedAt = time.Now().UTC()
-			if err := s.userRepo.UpdateUser(user); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
-				return
-			}
-		}
-	} else {
-		user = &infrastructure.User{
-			AndroidID:    &req.AndroidID,
-			Username:     req.Username,
-			Email:        nil, // Explicitly set to nil for guest users
-			PasswordHash: nil, // Explicitly set to nil for guest users
-			CreatedAt:    time.Now().UTC(),
-			UpdatedAt:    time.Now().UTC(),
-		}
-
-		if err := s.userRepo.CreateUser(user); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest user"})
-			return
-		}
-	}
-
-	jwtToken, err := generateJWT(*user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"token": jwtToken, "user": user})
-}
-
-// GoogleLoginHandler godoc
-// @Summary      Initiate Google OAuth login
-// @Description  Redirects the user to Google's OAuth2 authorization page
-// @Tags         Auth
-// @Produce      plain
-// @Success      307 {string} string "Redirects to Google OAuth2 page"
-// @Router       /auth/google/login [get]
-func (s *AuthService) GoogleLoginHandler(c *gin.Context) {
-	url := GoogleOAuthConfig.AuthCodeURL("state-token")
-	c.Redirect(http.StatusTemporaryRedirect, url)
-}
-
-// GoogleCallbackHandler godoc
-// @Summary      Handle Google OAuth callback
-// @Description  Processes the OAuth2 callback from Google and returns a JWT token
-// @Tags         Auth
-// @Produce      json
-// @Param        code query string true "Authorization code from Google"
-// @Param        state query string true "State token"
-// @Success      200 {object} dto.AuthResponse
-// @Failure      400 {object} dto.ErrorResponse
-// @Failure      500 {object} dto.ErrorResponse
-// @Router       /auth/google/callback [get]
-func (s *AuthService) GoogleCallbackHandler(c *gin.Context) {
-	code := c.Query("code")
-
-	token, err := GoogleOAuthConfig.Exchange(context.Background(), code)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Token exchange failed"})
-		return
-	}
-
-	client := GoogleOAuthConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get user info"})
-		return
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	var userInfo map[string]interface{}
-	json.Unmarshal(body, &userInfo)
-
-	googleID := userInfo["id"].(string)
-	email := userInfo["email"].(string)
-	username := userInfo["name"].(string)
-
-	user := &infrastructure.User{
-		GoogleID:     &googleID,
-		Email:        &email, // Use pointer to string
-		Username:     username,
-		PasswordHash: nil, // Google users don't have password
-		CreatedAt:    time.Now().UTC(),
-		UpdatedAt:    time.Now().UTC(),
-	}
-
-	// Create or update user in database
-	savedUser, err := s.userRepo.CreateOrUpdateUser(user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user", "details": err.Error()})
-		return
-	}
-
-	jwtToken, err := generateJWT(*savedUser)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"token": jwtToken, "user": savedUser})
-}
-
-// RegisterHandler godoc
-// @Summary      Register a new user
-// @Description  Creates a new user with username, email, and password
-// @Tags         Auth
-// @Accept       json
-// @Produce      json
-// @Param        request body dto.RegisterRequest true "User registration info"
-// @Success      201 {object} dto.AuthResponse
-// @Failure      400 {object} dto.ErrorResponse
-// @Failure      409 {object} dto.ErrorResponse
-// @Failure      500 {object} dto.ErrorResponse
-// @Router       /auth/register [post]
-func (s *AuthService) RegisterHandler(c *gin.Context) {
-	var req dto.RegisterRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	if _, exists := s.userRepo.UserExists(req.Email, ""); exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
-		return
-	}
-
-	hashedPassword, err := hashPassword(req.Password)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
-	}
-
-	user := &infrastructure.User{
-		Username:     req.Username,
-		Email:        &req.Email, // Use pointer to string
-		PasswordHash: &hashedPassword, // Use pointer to string
-		CreatedAt:    time.Now().UTC(),
-		UpdatedAt:    time.Now().UTC(),
-	}
-
-	if err := s.userRepo.CreateUser(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		return
-	}
-
-	jwtToken, err := generateJWT(*user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, gin.H{"token": jwtToken, "user": user})
-}
-
-// LoginHandler godoc
-// @Summary      Login
-// @Description  Login with email and password
-// @Tags         Auth
-// @Accept       json
-// @Produce      json
-// @Param        request body dto.LoginRequest true "Login credentials"
-// @Success      200 {object} dto.AuthResponse
-// @Failure      400 {object} dto.ErrorResponse
-// @Failure      401 {object} dto.ErrorResponse
-// @Failure      500 {object} dto.ErrorResponse
-// @Router       /auth/login [post]
-func (s *AuthService) LoginHandler(c *gin.Context) {
-	var req dto.LoginRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	user, err := s.userRepo.GetUserByEmail(req.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Check if user has a password (not a guest or OAuth user)
-	if user.PasswordHash == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	// Verify password 
-	if !verifyPassword(req.Password, *user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-		return
-	}
-
-	jwtToken, err := generateJWT(*user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"token": jwtToken, "user": user})
-}
-
-// GetProfileHandler godoc
-// @Summary      Get user profile
-// @Description  Returns the authenticated user's profile
-// @Tags         Auth
-// @Produce      json
-// @Security     ApiKeyAuth
-// @Success      200 {object} infrastructure.User
-// @Failure      401 {object} dto.ErrorResponse
-// @Failure      404 {object} dto.ErrorResponse
-// @Router       /profile [get]
-func (s *AuthService) GetProfileHandler(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-		return
-	}
-
-	id, err := strconv.ParseUint(userID.(string), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
-	user, err := s.userRepo.GetUserByID(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"user": user})
-}
-
-func generateJWT(user infrastructure.User) (string, error) {
-	var email string
-	if user.Email != nil {
-		email = *user.Email
-	}
-
-	claims := jwt.MapClaims{
-		"sub":      user.ID,
-		"email":    email,
-		"username": user.Username,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-}
-
-// hashPassword hashes a plain text password using bcrypt
-func hashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedBytes), nil
-}
-
-// verifyPassword compares a plain text password with a bcrypt hash
-func verifyPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+package auth
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+
+    "plantgo-backend/internal/dto"
+    "plantgo-backend/internal/mail"
+    "plantgo-backend/internal/modules/auth/infrastructure"
+    notificationinfra "plantgo-backend/internal/modules/notification/infrastructure"
+    "plantgo-backend/internal/storage"
+)
+
+type AuthService struct {
+	userRepo         *infrastructure.UserRepository
+	notificationRepo *notificationinfra.NotificationRepository
+	keys             *keyStore
+	googleIDTokens   *idTokenVerifier
+	appleIDTokens    *idTokenVerifier
+	mailer           mail.Sender
+	avatars          storage.Store
+	passwords        passwordPolicy
+}
+
+func NewAuthService(db *gorm.DB) *AuthService {
+	keys, err := loadKeyStore()
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	mailer, err := mail.NewSenderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mail: %v", err)
+	}
+	passwords, err := loadPasswordPolicy()
+	if err != nil {
+		log.Fatalf("Failed to load password policy: %v", err)
+	}
+	avatars, err := storage.NewStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure storage: %v", err)
+	}
+
+	s := &AuthService{
+		userRepo:         infrastructure.NewUserRepository(db),
+		notificationRepo: notificationinfra.NewNotificationRepository(db),
+		keys:             keys,
+		googleIDTokens:   newGoogleIDTokenVerifier(newRemoteKeySource(googleJWKSURL)),
+		appleIDTokens:    newAppleIDTokenVerifier(newRemoteKeySource(appleJWKSURL)),
+		mailer:           mailer,
+		avatars:          avatars,
+		passwords:        passwords,
+	}
+	s.bootstrapAdmins()
+	go s.runAccountPurger(accountPurgeInterval())
+	return s
+}
+
+// GuestLoginHandler godoc
+// @Summary      Guest login
+// @Description  Authenticates or creates a guest user using Android ID and username. The username only applies to a new guest and is adjusted if it is invalid or taken; use PATCH /me to change it. A new guest gets a device_secret in the response, which must be sent on every later guest login from that device. A device bound to an account with two-factor login gets a dto.TwoFactorChallengeResponse instead of tokens.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.GuestLoginRequest true "Guest login credentials"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/guest [post]
+func (s *AuthService) GuestLoginHandler(c *gin.Context) {
+	var req dto.GuestLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	if !s.checkLoginAllowed(c, "guest", req.AndroidID) {
+		return
+	}
+
+	var deviceSecret string
+	user, err := s.userRepo.GetUserByIdentity(infrastructure.ProviderAndroid, req.AndroidID)
+	if err == nil {
+		switch {
+		case user.DeviceSecretHash == nil && user.IsGuest():
+			// Guests created before device binding get a secret on their next login
+			secret, hash, err := newDeviceSecret()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create device secret"})
+				return
+			}
+			deviceSecret = secret
+			user.DeviceSecretHash = &hash
+		case !deviceSecretMatches(user, req.DeviceSecret):
+			s.recordLoginFailure(c, "guest", req.AndroidID, &user.ID, "bad_device_secret")
+			if user.IsGuest() {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid device credentials"})
+			} else {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in with your email or Google account to recover this device"})
+			}
+			return
+		}
+
+		// The username is only taken from the request when the account is
+		// created; later changes go through PATCH /me.
+		if deviceSecret != "" {
+			user.UpdatedAt = time.Now().UTC()
+			if err := s.userRepo.UpdateUser(user); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+				return
+			}
+		}
+	} else {
+		secret, hash, err := newDeviceSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create device secret"})
+			return
+		}
+		deviceSecret = secret
+
+		user = &infrastructure.User{
+			Username:         deriveUsername(req.Username),
+			Email:            nil, // Explicitly set to nil for guest users
+			PasswordHash:     nil, // Explicitly set to nil for guest users
+			DeviceSecretHash: &hash,
+			CreatedAt:        time.Now().UTC(),
+			UpdatedAt:        time.Now().UTC(),
+			Identities: []infrastructure.UserIdentity{
+				{Provider: infrastructure.ProviderAndroid, Subject: req.AndroidID},
+			},
+		}
+
+		// Guests don't choose their name on a sign-up form, so a taken or
+		// invalid one is adjusted instead of refused.
+		if err := s.userRepo.CreateUserWithUniqueUsername(user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest user"})
+			return
+		}
+	}
+
+	s.recordLoginSuccess(req.AndroidID)
+	s.respondWithDeviceSecret(c, user, deviceSecret)
+}
+
+// GoogleLoginHandler godoc
+// @Summary      Initiate Google OAuth login
+// @Description  Redirects the user to Google's OAuth2 authorization page. A random state and PKCE verifier are bound to the browser with a short-lived cookie.
+// @Tags         Auth
+// @Produce      plain
+// @Success      307 {string} string "Redirects to Google OAuth2 page"
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/google/login [get]
+func (s *AuthService) GoogleLoginHandler(c *gin.Context) {
+	state, err := randomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start Google login"})
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+	setOAuthCookies(c, state, verifier)
+
+	url := GoogleOAuthConfig.AuthCodeURL(state, oauth2.AccessTypeOnline, oauth2.S256ChallengeOption(verifier))
+	c.Redirect(http.StatusTemporaryRedirect, url)
+}
+
+// GoogleCallbackHandler godoc
+// @Summary      Handle Google OAuth callback
+// @Description  Verifies the state, exchanges the code with PKCE and returns a JWT token. Only Google accounts with a verified email are accepted.
+// @Tags         Auth
+// @Produce      json
+// @Param        code query string true "Authorization code from Google"
+// @Param        state query string true "State token"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Failure      502 {object} dto.ErrorResponse
+// @Router       /auth/google/callback [get]
+func (s *AuthService) GoogleCallbackHandler(c *gin.Context) {
+	expectedState, verifier, ok := readOAuthCookies(c)
+	clearOAuthCookies(c)
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Google login was not completed: " + errParam})
+		return
+	}
+	if !ok || !stateMatches(expectedState, c.Query("state")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired OAuth state"})
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing authorization code"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := GoogleOAuthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token exchange failed"})
+		return
+	}
+
+	userInfo, err := fetchGoogleUserInfo(ctx, token)
+	if err != nil {
+		log.Printf("Failed to get Google user info: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to get user info"})
+		return
+	}
+	if !userInfo.VerifiedEmail {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Google account email is not verified"})
+		return
+	}
+
+	savedUser, err := s.saveGoogleUser(userInfo)
+	if err != nil {
+		identitySignInFailed(c, err)
+		return
+	}
+
+	s.respondWithLogin(c, savedUser)
+}
+
+// GoogleIDTokenHandler godoc
+// @Summary      Sign in with a Google ID token
+// @Description  For native clients using Google Sign-In / Credential Manager. Verifies the ID token signature against Google's keys, then checks issuer, audience and expiry before returning a PlantGo JWT.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.GoogleIDTokenRequest true "Google ID token"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/google/token [post]
+func (s *AuthService) GoogleIDTokenHandler(c *gin.Context) {
+	var req dto.GoogleIDTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := s.googleIDTokens.verify(c.Request.Context(), req.IDToken)
+	if err != nil {
+		log.Printf("Rejected Google ID token: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Google ID token"})
+		return
+	}
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Google account email is not verified"})
+		return
+	}
+
+	savedUser, err := s.saveGoogleUser(&googleUserInfo{
+		ID:            claims.Subject,
+		Email:         claims.Email,
+		VerifiedEmail: true,
+		Name:          claims.Name,
+	})
+	if err != nil {
+		identitySignInFailed(c, err)
+		return
+	}
+
+	s.respondWithLogin(c, savedUser)
+}
+
+// RegisterHandler godoc
+// @Summary      Register a new user
+// @Description  Creates a new user with username, email, and password, and mails a link to verify the email. Usernames are 3-32 letters, digits, '.', '_' or '-' and unique regardless of case.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RegisterRequest true "User registration info"
+// @Success      201 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/register [post]
+func (s *AuthService) RegisterHandler(c *gin.Context) {
+	var req dto.RegisterRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := s.userRepo.GetUserByEmail(req.Email); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User with this email already exists"})
+		return
+	}
+	username, err := validateUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.passwords.validate(req.Password, username, req.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkUsernameAvailable(c, username, 0) {
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	user := &infrastructure.User{
+		Username:     username,
+		Email:        &req.Email, // Use pointer to string
+		PasswordHash: &hashedPassword, // Use pointer to string
+		Role:         infrastructure.RolePlayer, // bootstrap admins are promoted once they verify their email
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+
+	if err := s.userRepo.CreateUser(user); err != nil {
+		usernameSaveFailed(c, err, "Failed to create user")
+		return
+	}
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	s.respondWithTokens(c, http.StatusCreated, user)
+}
+
+// LoginHandler godoc
+// @Summary      Login
+// @Description  Login with email and password. If the account has two-factor login enabled, the response is a dto.TwoFactorChallengeResponse to complete at /auth/2fa/verify instead of tokens.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.LoginRequest true "Login credentials"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/login [post]
+func (s *AuthService) LoginHandler(c *gin.Context) {
+	var req dto.LoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.checkLoginAllowed(c, "password", req.Email) {
+		return
+	}
+
+	user, err := s.userRepo.GetUserByEmail(req.Email)
+	if err != nil {
+		// Spend the same time as a real check so unknown emails cannot be told apart
+		verifyPassword(req.Password, dummyPasswordHash())
+		s.recordLoginFailure(c, "password", req.Email, nil, "unknown_email")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	// Check if user has a password (not a guest or OAuth user)
+	if user.PasswordHash == nil {
+		verifyPassword(req.Password, dummyPasswordHash())
+		s.recordLoginFailure(c, "password", req.Email, &user.ID, "no_password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	// Verify password 
+	if !verifyPassword(req.Password, *user.PasswordHash) {
+		s.recordLoginFailure(c, "password", req.Email, &user.ID, "wrong_password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+
+	s.recordLoginSuccess(req.Email)
+	s.upgradePasswordHash(user, req.Password)
+	s.respondWithLogin(c, user)
+}
+
+// GetProfileHandler godoc
+// @Summary      Get user profile
+// @Description  Returns the authenticated user's profile
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} infrastructure.User
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Router       /profile [get]
+func (s *AuthService) GetProfileHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// hashPassword hashes a plain text password using bcrypt at passwordHashCost
+func hashPassword(password string) (string, error) {
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost())
+	if err != nil {
+		return "", err
+	}
+	return string(hashedBytes), nil
+}
+
+// verifyPassword compares a plain text password with a bcrypt hash
+func verifyPassword(password, hash string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
 }
\ No newline at end of file
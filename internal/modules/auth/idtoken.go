@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeySource resolves the public key a third-party identity provider used to
+// sign an ID token. It is an interface so tests can verify tokens offline.
+type KeySource interface {
+	PublicKey(ctx context.Context, kid string) (interface{}, error)
+}
+
+// StaticKeySource serves a fixed set of keys by kid.
+type StaticKeySource map[string]interface{}
+
+func (s StaticKeySource) PublicKey(_ context.Context, kid string) (interface{}, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+const (
+	defaultJWKSCacheTTL  = time.Hour
+	minJWKSRefreshPeriod = time.Minute
+)
+
+// remoteKeySource fetches a provider's JWKS and caches it for as long as the
+// provider's Cache-Control header allows. An unknown kid forces a refetch, at
+// most once per minJWKSRefreshPeriod, so a key rotation is picked up quickly
+// without letting bad tokens hammer the provider.
+type remoteKeySource struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	expiresAt time.Time
+	fetchedAt time.Time
+}
+
+func newRemoteKeySource(url string) *remoteKeySource {
+	return &remoteKeySource{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (r *remoteKeySource) PublicKey(ctx context.Context, kid string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if key, ok := r.keys[kid]; ok && now.Before(r.expiresAt) {
+		return key, nil
+	}
+	if now.Before(r.expiresAt) && now.Sub(r.fetchedAt) < minJWKSRefreshPeriod {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+
+	if err := r.refresh(ctx); err != nil {
+		// Keep serving the last good key set if the provider is briefly down.
+		if key, ok := r.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+func (r *remoteKeySource) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			continue // skip key types we do not use
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS contains no usable keys")
+	}
+
+	now := time.Now()
+	r.keys = keys
+	r.fetchedAt = now
+	r.expiresAt = now.Add(cacheMaxAge(resp.Header.Get("Cache-Control"), defaultJWKSCacheTTL))
+	return nil
+}
+
+// cacheMaxAge extracts max-age from a Cache-Control header.
+func cacheMaxAge(header string, fallback time.Duration) time.Duration {
+	for _, directive := range strings.Split(header, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(directive), "=")
+		if !found || !strings.EqualFold(name, "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return fallback
+}
+
+func (k JWK) rsaPublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// flexBool accepts both JSON booleans and the "true"/"false" strings some
+// providers (Apple) use for boolean claims.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseBool(strings.Trim(string(data), `"`))
+	if err != nil {
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	*b = flexBool(value)
+	return nil
+}
+
+// idTokenClaims are the OpenID Connect claims we read from third-party ID tokens.
+type idTokenClaims struct {
+	Email          string   `json:"email"`
+	EmailVerified  flexBool `json:"email_verified"`
+	IsPrivateEmail flexBool `json:"is_private_email"`
+	Name           string   `json:"name"`
+	Nonce          string   `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// idTokenVerifier checks an OpenID Connect ID token issued by a third party.
+type idTokenVerifier struct {
+	keys      KeySource
+	issuers   []string
+	audiences []string
+}
+
+var errIDTokenInvalid = errors.New("invalid ID token")
+
+// verify checks the RS256 signature against the provider's keys and the
+// issuer, audience and expiry claims.
+func (v *idTokenVerifier) verify(ctx context.Context, raw string) (*idTokenClaims, error) {
+	if len(v.audiences) == 0 {
+		return nil, errors.New("no audience configured for ID token verification")
+	}
+
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method %q", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.PublicKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errIDTokenInvalid, err)
+	}
+
+	if claims.ExpiresAt == nil || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing exp or sub", errIDTokenInvalid)
+	}
+	if !containsString(v.issuers, claims.Issuer) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", errIDTokenInvalid, claims.Issuer)
+	}
+	audienceOK := false
+	for _, aud := range claims.Audience {
+		if containsString(v.audiences, aud) {
+			audienceOK = true
+			break
+		}
+	}
+	if !audienceOK {
+		return nil, fmt.Errorf("%w: unexpected audience %v", errIDTokenInvalid, claims.Audience)
+	}
+	return claims, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// splitEnvList returns the non-empty, trimmed comma-separated values of key.
+func splitEnvList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
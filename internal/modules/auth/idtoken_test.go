@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func googleClaims(aud string, exp time.Time) *idTokenClaims {
+	return &idTokenClaims{
+		Email:         "player@example.com",
+		EmailVerified: true,
+		Name:          "Player",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://accounts.google.com",
+			Subject:   "1234567890",
+			Audience:  jwt.ClaimStrings{aud},
+			ExpiresAt: jwt.NewNumericDate(exp),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+}
+
+func TestIDTokenVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &idTokenVerifier{
+		keys:      StaticKeySource{"k1": &key.PublicKey},
+		issuers:   googleIDTokenIssuers,
+		audiences: []string{"web-client", "android-client"},
+	}
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+
+	claims, err := v.verify(ctx, signIDToken(t, key, "k1", googleClaims("android-client", future)))
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if claims.Subject != "1234567890" || claims.Email != "player@example.com" || !bool(claims.EmailVerified) {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	wrongIssuer := googleClaims("web-client", future)
+	wrongIssuer.Issuer = "https://evil.example.com"
+
+	cases := map[string]string{
+		"wrong audience": signIDToken(t, key, "k1", googleClaims("someone-else", future)),
+		"wrong issuer":   signIDToken(t, key, "k1", wrongIssuer),
+		"expired":        signIDToken(t, key, "k1", googleClaims("web-client", time.Now().Add(-time.Minute))),
+		"unknown kid":    signIDToken(t, key, "k2", googleClaims("web-client", future)),
+		"wrong key":      signIDToken(t, otherKey, "k1", googleClaims("web-client", future)),
+	}
+	for name, token := range cases {
+		if _, err := v.verify(ctx, token); !errors.Is(err, errIDTokenInvalid) {
+			t.Errorf("%s: expected errIDTokenInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestRemoteKeySourceCachesJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{{
+			Kty: "RSA",
+			Kid: "k1",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer srv.Close()
+
+	source := newRemoteKeySource(srv.URL)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		pub, err := source.PublicKey(ctx, "k1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !key.PublicKey.Equal(pub) {
+			t.Fatal("fetched key does not match")
+		}
+	}
+	if _, err := source.PublicKey(ctx, "missing"); err == nil {
+		t.Error("expected an error for an unknown kid")
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("expected the JWKS to be fetched once, got %d", n)
+	}
+}
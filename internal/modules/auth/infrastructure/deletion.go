@@ -0,0 +1,159 @@
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+	notificationinfra "plantgo-backend/internal/modules/notification/infrastructure"
+)
+
+// deletedIdentifier replaces emails and device IDs in records that are kept
+// for security auditing after their user is purged.
+const deletedIdentifier = "deleted-user"
+
+// ScheduleDeletion marks the user for purging at the given time.
+func (r *UserRepository) ScheduleDeletion(userID uint, at time.Time) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"deletion_scheduled_at": at, "updated_at": time.Now().UTC()}).Error
+}
+
+// CancelDeletion clears a pending deletion request.
+func (r *UserRepository) CancelDeletion(userID uint) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"deletion_scheduled_at": nil, "updated_at": time.Now().UTC()}).Error
+}
+
+// GetUsersDueForPurge returns up to limit users whose deletion grace period
+// ended before now.
+func (r *UserRepository) GetUsersDueForPurge(now time.Time, limit int) ([]User, error) {
+	var users []User
+	err := r.db.Unscoped().
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now).
+		Order("deletion_scheduled_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// PurgeUser permanently deletes the user and every row keyed by their ID.
+// Login attempts are kept for abuse detection but stripped of anything that
+// identifies the user. Everything runs in one transaction.
+func (r *UserRepository) PurgeUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user User
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, userID).Error; err != nil {
+			return fmt.Errorf("user with ID %d not found", userID)
+		}
+		if err := tx.Where("user_id = ?", userID).Find(&user.Identities).Error; err != nil {
+			return err
+		}
+		identifiers := userIdentifiers(&user)
+
+		owned := []interface{}{
+			&levelinfra.UserLevelProgress{},
+			&levelinfra.LevelAttempt{},
+			&levelinfra.UserReward{},
+			&levelinfra.CoinTransaction{},
+			&levelinfra.UserInventoryItem{},
+			&levelinfra.UserHintUnlock{},
+			&notificationinfra.Notification{},
+			&notificationinfra.UserNotificationPreference{},
+			&notificationinfra.UserFCMToken{},
+			&RefreshToken{},
+			&Session{},
+			&UserToken{},
+			&UserIdentity{},
+			&RecoveryCode{},
+		}
+		for _, model := range owned {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		attempts := tx.Model(&LoginAttempt{}).Where("user_id = ?", userID)
+		if len(identifiers) > 0 {
+			attempts = attempts.Or("identifier IN ?", identifiers)
+		}
+		if err := attempts.Updates(map[string]interface{}{
+			"user_id": nil, "identifier": deletedIdentifier, "user_agent": "",
+		}).Error; err != nil {
+			return err
+		}
+		if len(identifiers) > 0 {
+			if err := tx.Where("scope = ? AND key IN ?", ThrottleScopeAccount, identifiers).
+				Delete(&LoginThrottle{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&user).Error
+	})
+}
+
+// userIdentifiers lists the login identifiers the user may appear under in
+// throttling and audit records. Identities must be loaded.
+func userIdentifiers(user *User) []string {
+	var identifiers []string
+	if user.Email != nil && *user.Email != "" {
+		identifiers = append(identifiers, *user.Email, strings.ToLower(*user.Email))
+	}
+	if device, ok := user.Identity(ProviderAndroid); ok {
+		identifiers = append(identifiers, device.Subject)
+	}
+	return append(identifiers, TwoFactorThrottleKey(user.ID))
+}
+
+// UserDataExport is everything stored about one user.
+type UserDataExport struct {
+	ExportedAt              time.Time                                      `json:"exported_at"`
+	User                    User                                           `json:"user"`
+	Sessions                []Session                                      `json:"sessions"`
+	LoginAttempts           []LoginAttempt                                 `json:"login_attempts"`
+	LevelProgress           []levelinfra.UserLevelProgress                 `json:"level_progress"`
+	LevelAttempts           []levelinfra.LevelAttempt                      `json:"level_attempts"`
+	Rewards                 []levelinfra.UserReward                        `json:"rewards"`
+	CoinTransactions        []levelinfra.CoinTransaction                   `json:"coin_transactions"`
+	Inventory               []levelinfra.UserInventoryItem                 `json:"inventory"`
+	HintUnlocks             []levelinfra.UserHintUnlock                    `json:"hint_unlocks"`
+	Notifications           []notificationinfra.Notification               `json:"notifications"`
+	NotificationPreferences []notificationinfra.UserNotificationPreference `json:"notification_preferences"`
+	PushTokens              []notificationinfra.UserFCMToken               `json:"push_tokens"`
+}
+
+// ExportUserData collects every record keyed by the user's ID.
+func (r *UserRepository) ExportUserData(userID uint) (*UserDataExport, error) {
+	export := &UserDataExport{ExportedAt: time.Now().UTC()}
+	if err := r.db.Preload("Identities").First(&export.User, userID).Error; err != nil {
+		return nil, fmt.Errorf("user with ID %d not found", userID)
+	}
+
+	queries := []struct {
+		db   *gorm.DB
+		dest interface{}
+	}{
+		{r.db, &export.Sessions},
+		{r.db, &export.LoginAttempts},
+		{r.db.Preload("Level"), &export.LevelProgress},
+		{r.db, &export.LevelAttempts},
+		{r.db, &export.Rewards},
+		{r.db, &export.CoinTransactions},
+		{r.db.Preload("ShopItem", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }), &export.Inventory},
+		{r.db, &export.HintUnlocks},
+		{r.db, &export.Notifications},
+		{r.db, &export.NotificationPreferences},
+		{r.db, &export.PushTokens},
+	}
+	for _, q := range queries {
+		if err := q.db.Where("user_id = ?", userID).Order("id").Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
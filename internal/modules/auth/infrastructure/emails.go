@@ -0,0 +1,37 @@
+package infrastructure
+
+import "gorm.io/gorm"
+
+// emailIndex makes emails unique regardless of case.
+const emailIndex = "idx_users_email_lower"
+
+// EnsureEmailIndex builds the unique index on LOWER(email). Where accounts
+// share an email that differs only by case, the account that verified it
+// first keeps it, or the oldest if none did; the others lose the email and
+// can sign in through their identities or add it again. It must run after
+// AutoMigrate.
+func EnsureEmailIndex(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&User{}, emailIndex) {
+		return nil
+	}
+	err := db.Exec(`
+		UPDATE users u SET email = NULL, email_verified_at = NULL
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (
+				PARTITION BY LOWER(email)
+				ORDER BY email_verified_at ASC NULLS LAST, id ASC
+			) AS rank
+			FROM users
+			WHERE email IS NOT NULL
+		) ranked
+		WHERE u.id = ranked.id AND ranked.rank > 1`).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX " + emailIndex + " ON users (LOWER(email))").Error
+}
+
+// whereEmail matches users by email, ignoring case.
+func whereEmail(db *gorm.DB, email string) *gorm.DB {
+	return db.Where("LOWER(email) = LOWER(?)", email)
+}
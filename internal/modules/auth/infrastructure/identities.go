@@ -0,0 +1,269 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrIdentityTaken is returned when linking an identity that already belongs
+// to another user.
+var ErrIdentityTaken = errors.New("identity is linked to another account")
+
+// ErrEmailUnverified is returned when signing in with an identity whose email
+// belongs to an account that never verified it. Linking would hand the
+// identity to whoever registered the address.
+var ErrEmailUnverified = errors.New("email belongs to an account that has not verified it")
+
+// GetUserByIdentity returns the user the provider's subject is linked to.
+func (r *UserRepository) GetUserByIdentity(provider IdentityProvider, subject string) (*User, error) {
+	var identity UserIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no user linked to %s identity %s", provider, subject)
+		}
+		return nil, err
+	}
+	return r.GetUserByID(identity.UserID)
+}
+
+// GetUserIdentities lists every identity linked to the user.
+func (r *UserRepository) GetUserIdentities(userID uint) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// LinkIdentity attaches identity to identity.UserID, replacing any identity
+// the user already had with the same provider. It fails with
+// ErrIdentityTaken if the subject belongs to someone else.
+func (r *UserRepository) LinkIdentity(identity *UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return linkIdentity(tx, identity)
+	})
+}
+
+func linkIdentity(tx *gorm.DB, identity *UserIdentity) error {
+	var existing UserIdentity
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).
+		First(&existing).Error
+	switch {
+	case err == nil && existing.UserID != identity.UserID:
+		return ErrIdentityTaken
+	case err == nil:
+		existing.Email = identity.Email
+		existing.EmailVerified = identity.EmailVerified
+		if err := tx.Save(&existing).Error; err != nil {
+			return err
+		}
+		*identity = existing
+		return nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	if err := tx.Where("user_id = ? AND provider = ?", identity.UserID, identity.Provider).
+		Delete(&UserIdentity{}).Error; err != nil {
+		return err
+	}
+	identity.ID = 0
+	return tx.Create(identity).Error
+}
+
+// UnlinkIdentity removes the user's identity for provider.
+func (r *UserRepository) UnlinkIdentity(userID uint, provider IdentityProvider) error {
+	result := r.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no %s identity linked", provider)
+	}
+	return nil
+}
+
+// SignInWithIdentity returns the user for a verified external identity,
+// creating one from profile if needed. An identity seen for the first time
+// is linked to the account that already uses its email, but only when both
+// the provider and that account verified the email. Existing accounts keep
+// their username.
+func (r *UserRepository) SignInWithIdentity(identity *UserIdentity, profile *User) (*User, error) {
+	var userID uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existing).Error
+		if err == nil {
+			userID = existing.UserID
+			identity.UserID = existing.UserID
+			if err := linkIdentity(tx, identity); err != nil {
+				return err
+			}
+			return adoptIdentityEmail(tx, existing.UserID, identity)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if identity.Email != nil && identity.EmailVerified {
+			var owner User
+			err := whereEmail(tx, *identity.Email).First(&owner).Error
+			if err == nil {
+				if owner.EmailVerifiedAt == nil {
+					return ErrEmailUnverified
+				}
+				userID = owner.ID
+				identity.UserID = owner.ID
+				if err := linkIdentity(tx, identity); err != nil {
+					return err
+				}
+				return adoptIdentityEmail(tx, owner.ID, identity)
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		// An unverified email cannot link accounts; the new account goes
+		// without it if someone else already has it.
+		if profile.Email != nil {
+			var count int64
+			if err := whereEmail(tx.Model(&User{}), *profile.Email).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				profile.Email = nil
+				profile.EmailVerifiedAt = nil
+			}
+		}
+		// The username was derived from the provider's profile, so it is
+		// made unique rather than refused.
+		username, err := freeUsername(tx, profile.Username)
+		if err != nil {
+			return err
+		}
+		profile.Username = username
+		profile.Identities = nil
+		if err := tx.Create(profile).Error; err != nil {
+			return usernameConflict(err)
+		}
+		userID = profile.ID
+		identity.UserID = profile.ID
+		return linkIdentity(tx, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetUserByID(userID)
+}
+
+// adoptIdentityEmail gives a user without an email the identity's verified
+// email, if no other account uses it, and marks a matching email verified.
+func adoptIdentityEmail(tx *gorm.DB, userID uint, identity *UserIdentity) error {
+	if identity.Email == nil || !identity.EmailVerified {
+		return nil
+	}
+	var user User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case user.Email == nil || *user.Email == "":
+		var count int64
+		if err := whereEmail(tx.Model(&User{}), *identity.Email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"email": *identity.Email, "email_verified_at": now, "updated_at": now,
+		}).Error
+	case user.EmailVerifiedAt == nil && strings.EqualFold(*user.Email, *identity.Email):
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"email_verified_at": now, "updated_at": now,
+		}).Error
+	}
+	return nil
+}
+
+// legacyIdentityColumns are the users columns identities used to live in.
+var legacyIdentityColumns = []struct {
+	provider IdentityProvider
+	column   string
+}{
+	{ProviderAndroid, "android_id"},
+	{ProviderGoogle, "google_id"},
+}
+
+// ErrLegacyIdentitiesNotCopied is returned by DropLegacyIdentityColumns when
+// a legacy column still holds identities user_identities does not.
+var ErrLegacyIdentitiesNotCopied = errors.New("legacy identities were not all copied to user_identities")
+
+// MigrateLegacyIdentities copies identities out of the old users.android_id
+// and users.google_id columns into user_identities. Identities already
+// copied are skipped, and it does nothing once the columns are gone.
+func MigrateLegacyIdentities(db *gorm.DB) error {
+	for _, legacy := range legacyIdentityColumns {
+		if !db.Migrator().HasColumn(&User{}, legacy.column) {
+			continue
+		}
+		// Google accounts were created from verified Google profiles, so
+		// their email is carried over with the identity as verified.
+		emailExpr, verifiedExpr := "NULL", "FALSE"
+		if legacy.provider == ProviderGoogle {
+			emailExpr, verifiedExpr = "email", "TRUE"
+		}
+		if err := db.Exec(fmt.Sprintf(`
+			INSERT INTO user_identities (user_id, provider, subject, email, email_verified, created_at, updated_at)
+			SELECT id, ?, %[1]s, %[2]s, %[3]s, NOW(), NOW()
+			FROM users
+			WHERE %[1]s IS NOT NULL AND %[1]s <> ''
+			ON CONFLICT DO NOTHING`, legacy.column, emailExpr, verifiedExpr),
+			legacy.provider).Error; err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", legacy.column, err)
+		}
+	}
+	return nil
+}
+
+// DropLegacyIdentityColumns drops users.android_id and users.google_id once
+// every identity in them is in user_identities, linked to the same user. A
+// column that still holds an identity that was not copied is kept, and
+// ErrLegacyIdentitiesNotCopied is returned.
+func DropLegacyIdentityColumns(db *gorm.DB) error {
+	var kept []string
+	for _, legacy := range legacyIdentityColumns {
+		if !db.Migrator().HasColumn(&User{}, legacy.column) {
+			continue
+		}
+		var missing int64
+		if err := db.Raw(fmt.Sprintf(`
+			SELECT COUNT(*) FROM users u
+			WHERE u.%[1]s IS NOT NULL AND u.%[1]s <> ''
+				AND NOT EXISTS (
+					SELECT 1 FROM user_identities i
+					WHERE i.user_id = u.id AND i.provider = ? AND i.subject = u.%[1]s
+				)`, legacy.column), legacy.provider).Scan(&missing).Error; err != nil {
+			return err
+		}
+		if missing > 0 {
+			kept = append(kept, fmt.Sprintf("%s (%d not copied)", legacy.column, missing))
+			continue
+		}
+		if err := db.Migrator().DropColumn(&User{}, legacy.column); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", legacy.column, err)
+		}
+	}
+	if len(kept) > 0 {
+		return fmt.Errorf("%w: %s", ErrLegacyIdentitiesNotCopied, strings.Join(kept, ", "))
+	}
+	return nil
+}
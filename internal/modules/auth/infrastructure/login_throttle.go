@@ -0,0 +1,70 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *UserRepository) GetLoginThrottle(scope ThrottleScope, key string) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	err := r.db.Where("scope = ? AND key = ?", scope, key).First(&throttle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("login throttle not found")
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordLoginFailure counts a failed login against scope/key and locks it for
+// lockFor(failures). Failures older than window are forgotten first. The row
+// is locked while it is updated so concurrent failures are all counted.
+func (r *UserRepository) RecordLoginFailure(scope ThrottleScope, key string, window time.Duration, lockFor func(failures int) time.Duration) (*LoginThrottle, error) {
+	var throttle LoginThrottle
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&LoginThrottle{Scope: scope, Key: key, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("scope = ? AND key = ?", scope, key).First(&throttle).Error; err != nil {
+			return err
+		}
+
+		if now.Sub(throttle.LastFailureAt) > window {
+			throttle.Failures = 0
+		}
+		throttle.Failures++
+		throttle.LastFailureAt = now
+		if d := lockFor(throttle.Failures); d > 0 {
+			until := now.Add(d)
+			throttle.LockedUntil = &until
+		}
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// ClearLoginFailures forgets the failures counted against scope/key.
+func (r *UserRepository) ClearLoginFailures(scope ThrottleScope, key string) error {
+	return r.db.Where("scope = ? AND key = ?", scope, key).Delete(&LoginThrottle{}).Error
+}
+
+func (r *UserRepository) CreateLoginAttempt(attempt *LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// DeleteLoginAttemptsBefore prunes audit records older than cutoff.
+func (r *UserRepository) DeleteLoginAttemptsBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", cutoff).Delete(&LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,345 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+	notificationinfra "plantgo-backend/internal/modules/notification/infrastructure"
+)
+
+// progressMerge describes how a source account's level progress is folded
+// into a target account.
+type progressMerge struct {
+	// move are source rows for levels the target has never touched.
+	move []uint
+	// complete maps target row IDs to the completion time they take over
+	// from the source account.
+	complete map[uint]time.Time
+	// rewards maps incomplete target rows to the reward the source's
+	// completion of the level paid.
+	rewards map[uint]int
+	// drop are source rows superseded by a target row.
+	drop []uint
+	// doublePaid is what the source's completions of levels the target also
+	// completed paid. Each level is only paid once after the merge.
+	doublePaid int
+}
+
+// planProgressMerge decides, level by level, which progress survives. A
+// completed row always wins over an incomplete one and the earliest
+// completion time is kept, so merging is deterministic and never loses a
+// completed level.
+func planProgressMerge(source, target []levelinfra.UserLevelProgress) progressMerge {
+	plan := progressMerge{complete: map[uint]time.Time{}, rewards: map[uint]int{}}
+
+	byLevel := make(map[uint]levelinfra.UserLevelProgress, len(target))
+	for _, p := range target {
+		byLevel[p.LevelID] = p
+	}
+
+	for _, src := range source {
+		dst, ok := byLevel[src.LevelID]
+		if !ok {
+			plan.move = append(plan.move, src.ID)
+			byLevel[src.LevelID] = src
+			continue
+		}
+		plan.drop = append(plan.drop, src.ID)
+		if !src.IsCompleted {
+			continue
+		}
+		if dst.IsCompleted {
+			plan.doublePaid += src.RewardEarned
+		} else {
+			plan.rewards[dst.ID] = src.RewardEarned
+		}
+		srcAt := completedAt(src)
+		if !dst.IsCompleted || srcAt.Before(completedAt(dst)) {
+			plan.complete[dst.ID] = srcAt
+		}
+	}
+	return plan
+}
+
+func completedAt(p levelinfra.UserLevelProgress) time.Time {
+	if p.CompletedAt != nil {
+		return *p.CompletedAt
+	}
+	return p.UpdatedAt
+}
+
+// mergedTotalRewards adds both balances and takes back the reward paid twice
+// for levels completed on both accounts, never dropping below the larger of
+// the two balances.
+func mergedTotalRewards(source, target, doublePaid int) int {
+	total := source + target - doublePaid
+	if total < source {
+		total = source
+	}
+	if total < target {
+		total = target
+	}
+	return total
+}
+
+// MergeUsers moves everything owned by sourceID onto targetID and soft-deletes
+// the source account. It is used when a guest links an identity that already
+// belongs to another account. The whole merge runs in one transaction.
+func (r *UserRepository) MergeUsers(sourceID, targetID uint) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge user %d into itself", sourceID)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var users []User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{sourceID, targetID}).Order("id").Find(&users).Error; err != nil {
+			return err
+		}
+		var source, target *User
+		for i := range users {
+			switch users[i].ID {
+			case sourceID:
+				source = &users[i]
+			case targetID:
+				target = &users[i]
+			}
+		}
+		if source == nil || target == nil {
+			return fmt.Errorf("user not found")
+		}
+
+		doublePaid, err := mergeProgress(tx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		if err := mergeRewards(tx, sourceID, targetID, doublePaid); err != nil {
+			return err
+		}
+		if err := mergeInventory(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := mergeNotifications(tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		if err := r.revokeUserRefreshTokens(tx, sourceID, 0); err != nil {
+			return err
+		}
+
+		if err := mergeIdentities(tx, source, target); err != nil {
+			return err
+		}
+
+		return tx.Delete(source).Error
+	})
+}
+
+// mergeIdentities moves the source's identities to the target for every
+// provider the target has none for; the rest are dropped with the source.
+// The device that played as the guest thereby keeps landing on the merged
+// account, with the same device secret.
+func mergeIdentities(tx *gorm.DB, source, target *User) error {
+	var sourceIdentities, targetIdentities []UserIdentity
+	if err := tx.Where("user_id = ?", source.ID).Find(&sourceIdentities).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", target.ID).Find(&targetIdentities).Error; err != nil {
+		return err
+	}
+	taken := make(map[IdentityProvider]bool, len(targetIdentities))
+	for _, identity := range targetIdentities {
+		taken[identity.Provider] = true
+	}
+
+	for _, identity := range sourceIdentities {
+		if taken[identity.Provider] {
+			if err := tx.Delete(&identity).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tx.Model(&identity).Update("user_id", target.ID).Error; err != nil {
+			return err
+		}
+		if identity.Provider == ProviderAndroid {
+			if err := tx.Model(target).Update("device_secret_hash", source.DeviceSecretHash).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(source).Update("device_secret_hash", nil).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeProgress applies planProgressMerge and returns what the source was
+// paid for levels completed on both accounts.
+func mergeProgress(tx *gorm.DB, sourceID, targetID uint) (int, error) {
+	var source, target []levelinfra.UserLevelProgress
+	if err := tx.Where("user_id = ?", sourceID).Order("id").Find(&source).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("user_id = ?", targetID).Order("id").Find(&target).Error; err != nil {
+		return 0, err
+	}
+
+	plan := planProgressMerge(source, target)
+
+	if len(plan.move) > 0 {
+		// Idempotency keys are per user and could collide on the target.
+		if err := tx.Model(&levelinfra.UserLevelProgress{}).
+			Where("id IN ?", plan.move).
+			Updates(map[string]interface{}{"user_id": targetID, "idempotency_key": nil}).Error; err != nil {
+			return 0, err
+		}
+	}
+	for id, at := range plan.complete {
+		updates := map[string]interface{}{"is_completed": true, "completed_at": at}
+		if reward, ok := plan.rewards[id]; ok {
+			updates["reward_earned"] = reward
+		}
+		if err := tx.Model(&levelinfra.UserLevelProgress{}).Where("id = ?", id).
+			Updates(updates).Error; err != nil {
+			return 0, err
+		}
+	}
+	if len(plan.drop) > 0 {
+		if err := tx.Delete(&levelinfra.UserLevelProgress{}, plan.drop).Error; err != nil {
+			return 0, err
+		}
+	}
+	// Answer history is kept whole on the target.
+	if err := tx.Model(&levelinfra.LevelAttempt{}).
+		Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+		return 0, err
+	}
+	// Hints unlocked on either account count against the merged one; a
+	// hint unlocked on both is kept once.
+	if err := tx.Where("user_id = ? AND hint_id IN (?)", sourceID,
+		tx.Model(&levelinfra.UserHintUnlock{}).Select("hint_id").Where("user_id = ?", targetID)).
+		Delete(&levelinfra.UserHintUnlock{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&levelinfra.UserHintUnlock{}).
+		Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+		return 0, err
+	}
+
+	return plan.doublePaid, nil
+}
+
+func mergeRewards(tx *gorm.DB, sourceID, targetID uint, doublePaid int) error {
+	var source levelinfra.UserReward
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", sourceID).First(&source).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&levelinfra.UserReward{UserID: targetID, LevelReached: 1}).Error; err != nil {
+		return err
+	}
+	var target levelinfra.UserReward
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", targetID).First(&target).Error; err != nil {
+		return err
+	}
+
+	// The source's ledger is left as it is and closed with one entry; one
+	// entry on the target credits what it gains, less what was paid twice.
+	merged := mergedTotalRewards(source.TotalRewards, target.TotalRewards, doublePaid)
+	if err := levelinfra.CloseCoinLedger(tx, &source, targetID); err != nil {
+		return err
+	}
+	if gained := merged - target.TotalRewards; gained != 0 {
+		if err := levelinfra.RecordCoinTransaction(tx, &target, &levelinfra.CoinTransaction{
+			Amount:         gained,
+			Reason:         levelinfra.CoinReasonAccountMerge,
+			SourceType:     levelinfra.CoinSourceUser,
+			SourceID:       &sourceID,
+			IdempotencyKey: levelinfra.CoinKey(levelinfra.CoinReasonAccountMerge, sourceID),
+		}); err != nil {
+			return err
+		}
+	}
+	if source.LevelReached > target.LevelReached {
+		target.LevelReached = source.LevelReached
+	}
+	if err := tx.Save(&target).Error; err != nil {
+		return err
+	}
+	// user_rewards.user_id is unique, so the old row is removed for good.
+	return tx.Unscoped().Delete(&source).Error
+}
+
+// mergeInventory moves the source's shop items to the target. Stackable
+// items the target also holds are added up; for items owned once the
+// target's copy is kept.
+func mergeInventory(tx *gorm.DB, sourceID, targetID uint) error {
+	var source []levelinfra.UserInventoryItem
+	if err := tx.Preload("ShopItem", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", sourceID).Find(&source).Error; err != nil {
+		return err
+	}
+	for _, item := range source {
+		var held levelinfra.UserInventoryItem
+		err := tx.Where("user_id = ? AND shop_item_id = ?", targetID, item.ShopItemID).First(&held).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Model(&item).Update("user_id", targetID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if item.ShopItem.Type.Stackable() {
+			if err := tx.Model(&held).
+				Update("quantity", gorm.Expr("quantity + ?", item.Quantity)).Error; err != nil {
+				return err
+			}
+		} else if held.Quantity == 0 && item.Quantity > 0 {
+			if err := tx.Model(&held).Update("quantity", item.Quantity).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeNotifications(tx *gorm.DB, sourceID, targetID uint) error {
+	if err := tx.Model(&notificationinfra.Notification{}).
+		Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&notificationinfra.UserFCMToken{}).
+		Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+		return err
+	}
+
+	// The target's preferences win; the guest's are only kept if the target
+	// never saved any.
+	var count int64
+	if err := tx.Model(&notificationinfra.UserNotificationPreference{}).
+		Where("user_id = ?", targetID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return tx.Model(&notificationinfra.UserNotificationPreference{}).
+			Where("user_id = ?", sourceID).Update("user_id", targetID).Error
+	}
+	return tx.Unscoped().Where("user_id = ?", sourceID).
+		Delete(&notificationinfra.UserNotificationPreference{}).Error
+}
@@ -0,0 +1,65 @@
+package infrastructure
+
+import (
+	"testing"
+	"time"
+
+	levelinfra "plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestPlanProgressMerge(t *testing.T) {
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(24 * time.Hour)
+
+	target := []levelinfra.UserLevelProgress{
+		{ID: 1, LevelID: 10, IsCompleted: true, CompletedAt: &late, RewardEarned: 30},
+		{ID: 2, LevelID: 20, IsCompleted: false},
+		{ID: 3, LevelID: 30, IsCompleted: true, CompletedAt: &early},
+	}
+	source := []levelinfra.UserLevelProgress{
+		{ID: 11, LevelID: 10, IsCompleted: true, CompletedAt: &early, RewardEarned: 15}, // earlier completion wins
+		{ID: 12, LevelID: 20, IsCompleted: true, CompletedAt: &late, RewardEarned: 20},  // completes target row
+		{ID: 13, LevelID: 30, IsCompleted: false},                                       // target already done
+		{ID: 14, LevelID: 40, IsCompleted: true, CompletedAt: &late},                    // only on guest
+	}
+
+	plan := planProgressMerge(source, target)
+
+	if len(plan.move) != 1 || plan.move[0] != 14 {
+		t.Errorf("expected to move row 14, got %v", plan.move)
+	}
+	if len(plan.drop) != 3 {
+		t.Errorf("expected 3 dropped rows, got %v", plan.drop)
+	}
+	if at, ok := plan.complete[1]; !ok || !at.Equal(early) {
+		t.Errorf("expected row 1 to take the earlier completion, got %v", plan.complete)
+	}
+	if at, ok := plan.complete[2]; !ok || !at.Equal(late) {
+		t.Errorf("expected row 2 to be completed, got %v", plan.complete)
+	}
+	if _, ok := plan.complete[3]; ok {
+		t.Error("row 3 should be left alone")
+	}
+	if reward, ok := plan.rewards[2]; !ok || reward != 20 || len(plan.rewards) != 1 {
+		t.Errorf("expected row 2 to take over the guest's reward, got %v", plan.rewards)
+	}
+	// Level 10 was paid on both accounts; the guest's payment, not the
+	// level's current reward, is taken back.
+	if plan.doublePaid != 15 {
+		t.Errorf("expected 15 paid twice, got %d", plan.doublePaid)
+	}
+}
+
+func TestMergedTotalRewards(t *testing.T) {
+	cases := []struct{ source, target, doublePaid, want int }{
+		{100, 50, 0, 150},
+		{100, 50, 30, 120},
+		{100, 50, 200, 100},
+		{0, 0, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := mergedTotalRewards(tc.source, tc.target, tc.doublePaid); got != tc.want {
+			t.Errorf("mergedTotalRewards(%d, %d, %d) = %d, want %d", tc.source, tc.target, tc.doublePaid, got, tc.want)
+		}
+	}
+}
@@ -1,37 +1,339 @@
-package infrastructure
-
-import (
-	"time"
-	"gorm.io/gorm"
-)
-
-type User struct {
-	ID           uint           `json:"id" gorm:"primaryKey" db:"id"`
-	Username     string         `json:"username" gorm:"not null;size:255" db:"username"`
-	Email        *string        `json:"email,omitempty" gorm:"uniqueIndex;size:255" db:"email"` // Make nullable
-	PasswordHash *string        `json:"-" gorm:"column:password_hash;size:255" db:"password_hash"` // Make nullable for guests
-	AndroidID    *string        `json:"android_id,omitempty" gorm:"uniqueIndex;column:android_id;size:255" db:"android_id"` // Add unique index
-	GoogleID     *string        `json:"google_id,omitempty" gorm:"uniqueIndex;column:google_id;size:255" db:"google_id"` // Add unique index
-	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"` 
-}
-
-func (User) TableName() string {
-	return "users"
-}
-
-func (u *User) BeforeCreate(tx *gorm.DB) error {
-	if u.CreatedAt.IsZero() {
-		u.CreatedAt = time.Now().UTC()
-	}
-	if u.UpdatedAt.IsZero() {
-		u.UpdatedAt = time.Now().UTC()
-	}
-	return nil
-}
-
-func (u *User) BeforeUpdate(tx *gorm.DB) error {
-	u.UpdatedAt = time.Now().UTC()
-	return nil
-}
\ No newline at end of file
+package infrastructure
+
+import (
+	"time"
+	"gorm.io/gorm"
+)
+
+// Role controls which parts of the API a user may access.
+type Role string
+
+const (
+	RolePlayer        Role = "player"
+	RoleContentEditor Role = "content-editor"
+	RoleAdmin         Role = "admin"
+)
+
+// IsValid reports whether r is one of the known roles.
+func (r Role) IsValid() bool {
+	switch r {
+	case RolePlayer, RoleContentEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+type User struct {
+	ID                  uint           `json:"id" gorm:"primaryKey" db:"id"`
+	Username            string         `json:"username" gorm:"not null;size:255" db:"username"`
+	DisplayName         string         `json:"display_name,omitempty" gorm:"size:64" db:"display_name"`
+	AvatarURL           string         `json:"avatar_url,omitempty" gorm:"size:512" db:"avatar_url"`
+	AvatarKey           string         `json:"-" gorm:"size:255" db:"avatar_key"` // Storage key of the current avatar
+	Bio                 string         `json:"bio,omitempty" gorm:"size:280" db:"bio"`
+	Country             string         `json:"country,omitempty" gorm:"size:2" db:"country"` // ISO 3166-1 alpha-2
+	PreferredLanguage   string         `json:"preferred_language,omitempty" gorm:"size:16" db:"preferred_language"` // BCP 47 tag
+	Email               *string        `json:"email,omitempty" gorm:"uniqueIndex;size:255" db:"email"` // Make nullable
+	PasswordHash        *string        `json:"-" gorm:"column:password_hash;size:255" db:"password_hash"` // Make nullable for guests
+	DeviceSecretHash    *string        `json:"-" gorm:"column:device_secret_hash;size:64" db:"device_secret_hash"` // SHA-256 of the guest device secret
+	TOTPSecret          *string        `json:"-" gorm:"column:totp_secret;size:64" db:"totp_secret"` // Base32 TOTP secret, pending until TOTPEnabledAt is set
+	TOTPEnabledAt       *time.Time     `json:"two_factor_enabled_at,omitempty" gorm:"column:totp_enabled_at" db:"totp_enabled_at"`
+	TOTPLastStep        int64          `json:"-" gorm:"column:totp_last_step;not null;default:0" db:"totp_last_step"` // Last accepted time step, so a code works once
+	Role                Role           `json:"role" gorm:"not null;size:32;default:player" db:"role"`
+	EmailVerifiedAt     *time.Time     `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	DeletionScheduledAt *time.Time     `json:"deletion_scheduled_at,omitempty" gorm:"index" db:"deletion_scheduled_at"` // Set while a deletion request is in its grace period
+	CreatedAt           time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"` 
+	Identities          []UserIdentity `json:"identities,omitempty" gorm:"foreignKey:UserID"` // Device and external sign-in accounts
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// IsGuest reports whether the user only has a device identity and no email or
+// external account linked yet. Identities must be loaded.
+func (u *User) IsGuest() bool {
+	if u.Email != nil && *u.Email != "" {
+		return false
+	}
+	for _, identity := range u.Identities {
+		if identity.Provider != ProviderAndroid {
+			return false
+		}
+	}
+	return true
+}
+
+// TwoFactorEnabled reports whether logins need a TOTP or recovery code.
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
+}
+
+// Identity returns the user's loaded identity for provider, if any.
+func (u *User) Identity(provider IdentityProvider) (*UserIdentity, bool) {
+	for i := range u.Identities {
+		if u.Identities[i].Provider == provider {
+			return &u.Identities[i], true
+		}
+	}
+	return nil, false
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.Role == "" {
+		u.Role = RolePlayer
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now().UTC()
+	}
+	if u.UpdatedAt.IsZero() {
+		u.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (u *User) BeforeUpdate(tx *gorm.DB) error {
+	u.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// IdentityProvider names a system that vouches for a user's identity.
+type IdentityProvider string
+
+const (
+	// ProviderAndroid is the guest device identity; its subject is the
+	// Android ID and the device secret on User proves it.
+	ProviderAndroid IdentityProvider = "android"
+	ProviderGoogle  IdentityProvider = "google"
+	ProviderApple   IdentityProvider = "apple"
+)
+
+// UserIdentity links a user to an account at a provider. A subject belongs
+// to at most one user and a user has at most one identity per provider, so
+// new providers need no changes to the users table.
+type UserIdentity struct {
+	ID            uint             `json:"id" gorm:"primaryKey" db:"id"`
+	UserID        uint             `json:"-" gorm:"not null;uniqueIndex:idx_user_identities_user_provider" db:"user_id"`
+	Provider      IdentityProvider `json:"provider" gorm:"not null;size:32;uniqueIndex:idx_user_identities_provider_subject;uniqueIndex:idx_user_identities_user_provider" db:"provider"`
+	Subject       string           `json:"subject" gorm:"not null;size:255;uniqueIndex:idx_user_identities_provider_subject" db:"subject"`
+	Email         *string          `json:"email,omitempty" gorm:"size:255" db:"email"` // Email the provider reported, if any
+	EmailVerified bool             `json:"email_verified" gorm:"not null;default:false" db:"email_verified"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().UTC()
+	if i.CreatedAt.IsZero() {
+		i.CreatedAt = now
+	}
+	if i.UpdatedAt.IsZero() {
+		i.UpdatedAt = now
+	}
+	return nil
+}
+
+func (i *UserIdentity) BeforeUpdate(tx *gorm.DB) error {
+	i.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// RefreshToken is a long-lived credential exchanged for new access tokens.
+// Only a SHA-256 hash of the token is stored. Every token issued from the same
+// login shares a FamilyID; each refresh rotates to a new token in the family
+// and marks the previous one as rotated.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey" db:"id"`
+	UserID    uint       `json:"user_id" gorm:"not null;index" db:"user_id"`
+	FamilyID  string     `json:"family_id" gorm:"not null;size:64;index" db:"family_id"`
+	TokenHash string     `json:"-" gorm:"not null;size:64;uniqueIndex" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null" db:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (t *RefreshToken) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// TokenPurpose says what a UserToken may be used for.
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+// UserToken is a single-use, expiring token mailed to a user, e.g. to verify
+// their email or reset their password. Only a SHA-256 hash is stored. Email
+// records the address the token was sent to.
+type UserToken struct {
+	ID        uint         `json:"id" gorm:"primaryKey" db:"id"`
+	UserID    uint         `json:"user_id" gorm:"not null;index" db:"user_id"`
+	Purpose   TokenPurpose `json:"purpose" gorm:"not null;size:32" db:"purpose"`
+	Email     string       `json:"email" gorm:"not null;size:255" db:"email"`
+	TokenHash string       `json:"-" gorm:"not null;size:64;uniqueIndex" db:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" gorm:"not null" db:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+func (t *UserToken) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+// ThrottleScope is what a LoginThrottle counts failures for.
+type ThrottleScope string
+
+const (
+	ThrottleScopeAccount ThrottleScope = "account"
+	ThrottleScopeIP      ThrottleScope = "ip"
+)
+
+// LoginThrottle counts recent failed logins for an account identifier or a
+// client IP and holds the lockout they triggered.
+type LoginThrottle struct {
+	ID            uint          `json:"id" gorm:"primaryKey" db:"id"`
+	Scope         ThrottleScope `json:"scope" gorm:"not null;size:16;uniqueIndex:idx_login_throttles_scope_key" db:"scope"`
+	Key           string        `json:"key" gorm:"not null;size:255;uniqueIndex:idx_login_throttles_scope_key" db:"key"`
+	Failures      int           `json:"failures" gorm:"not null;default:0" db:"failures"`
+	LastFailureAt time.Time     `json:"last_failure_at" db:"last_failure_at"`
+	LockedUntil   *time.Time    `json:"locked_until,omitempty" db:"locked_until"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+func (t *LoginThrottle) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now().UTC()
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (t *LoginThrottle) BeforeUpdate(tx *gorm.DB) error {
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// LoginAttempt is an audit record of a failed or blocked login.
+type LoginAttempt struct {
+	ID         uint      `json:"id" gorm:"primaryKey" db:"id"`
+	UserID     *uint     `json:"user_id,omitempty" gorm:"index" db:"user_id"`
+	Method     string    `json:"method" gorm:"not null;size:32" db:"method"`
+	Identifier string    `json:"identifier" gorm:"not null;size:255;index" db:"identifier"`
+	IP         string    `json:"ip" gorm:"size:64;index" db:"ip"`
+	UserAgent  string    `json:"user_agent" gorm:"size:255" db:"user_agent"`
+	Reason     string    `json:"reason" gorm:"not null;size:64" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index" db:"created_at"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+// Session is one signed-in device. It lives as long as its refresh token
+// family and every access token minted for it carries its ID.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey" db:"id"`
+	UserID     uint       `json:"user_id" gorm:"not null;index" db:"user_id"`
+	FamilyID   string     `json:"-" gorm:"not null;size:64;uniqueIndex" db:"family_id"`
+	Platform   string     `json:"platform" gorm:"size:32" db:"platform"`
+	AppVersion string     `json:"app_version" gorm:"size:32" db:"app_version"`
+	DeviceName string     `json:"device_name" gorm:"size:255" db:"device_name"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255" db:"user_agent"`
+	LastIP     string     `json:"last_ip" gorm:"size:64" db:"last_ip"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	// TwoFactorAt is set when the login that started the session passed a
+	// second factor.
+	TwoFactorAt *time.Time `json:"two_factor_at,omitempty" db:"two_factor_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	now := time.Now().UTC()
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = now
+	}
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = now
+	}
+	if s.LastSeenAt.IsZero() {
+		s.LastSeenAt = now
+	}
+	return nil
+}
+
+func (s *Session) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// RecoveryCode is a one-time code that stands in for a TOTP code when the
+// authenticator is lost. Only a SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey" db:"id"`
+	UserID    uint       `json:"user_id" gorm:"not null;index" db:"user_id"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64;uniqueIndex" db:"code_hash"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now().UTC()
+	}
+	return nil
+}
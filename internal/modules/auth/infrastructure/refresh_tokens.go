@@ -0,0 +1,100 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenReused is returned when a token that was already rotated or
+// revoked is presented again.
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+func (r *UserRepository) CreateRefreshToken(token *RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *UserRepository) GetRefreshTokenByHash(hash string) (*RefreshToken, error) {
+	var token RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marks current as rotated and stores next in one
+// transaction. The conditional update makes two concurrent refreshes with the
+// same token race safely: only one of them wins, the other gets
+// ErrRefreshTokenReused.
+func (r *UserRepository) RotateRefreshToken(current *RefreshToken, next *RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		result := tx.Model(&RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"rotated_at": now, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeRefreshTokenFamily revokes every token descended from the same login,
+// and the session they belong to.
+func (r *UserRepository) RevokeRefreshTokenFamily(familyID string) error {
+	now := time.Now().UTC()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Session{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	})
+}
+
+// RevokeUserRefreshTokens revokes every refresh token and session the user
+// holds.
+func (r *UserRepository) RevokeUserRefreshTokens(userID uint) error {
+	return r.revokeUserRefreshTokens(r.db, userID, 0)
+}
+
+// RevokeOtherRefreshTokens revokes every refresh token and session the user
+// holds except those of keepSessionID.
+func (r *UserRepository) RevokeOtherRefreshTokens(userID, keepSessionID uint) error {
+	return r.revokeUserRefreshTokens(r.db, userID, keepSessionID)
+}
+
+func (r *UserRepository) revokeUserRefreshTokens(db *gorm.DB, userID, keepSessionID uint) error {
+	now := time.Now().UTC()
+	return db.Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Model(&Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		tokens := tx.Model(&RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+		if keepSessionID != 0 {
+			sessions = sessions.Where("id <> ?", keepSessionID)
+			tokens = tokens.Where("family_id NOT IN (?)",
+				tx.Model(&Session{}).Select("family_id").Where("id = ?", keepSessionID))
+		}
+		if err := tokens.Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		return sessions.Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	})
+}
+
+// DeleteExpiredRefreshTokens removes tokens that expired before cutoff.
+func (r *UserRepository) DeleteExpiredRefreshTokens(cutoff time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", cutoff).Delete(&RefreshToken{})
+	return result.RowsAffected, result.Error
+}
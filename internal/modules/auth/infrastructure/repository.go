@@ -1,147 +1,110 @@
-package infrastructure
-
-import (
-	"errors"
-	"fmt"
-	"gorm.io/gorm"
-)
-
-type UserRepository struct {
-	db *gorm.DB
-}
-
-func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
-}
-
-func (r *UserRepository) CreateUser(user *User) error {
-	return r.db.Create(user).Error
-}
-
-func (r *UserRepository) GetUserByID(id uint) (*User, error) {
-	var user User
-	err := r.db.First(&user, id).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("user with ID %d not found", id)
-		}
-		return nil, err
-	}
-	return &user, nil
-}
-
-func (r *UserRepository) GetUserByEmail(email string) (*User, error) {
-	var user User
-	err := r.db.Where("email = ?", email).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("user with email %s not found", email)
-		}
-		return nil, err
-	}
-	return &user, nil
-}
-
-func (r *UserRepository) GetUserByGoogleID(googleID string) (*User, error) {
-	var user User
-	err := r.db.Where("google_id = ?", googleID).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("user with Google ID %s not found", googleID)
-		}
-		return nil, err
-	}
-	return &user, nil
-}
-
-func (r *UserRepository) GetUserByAndroidID(androidID string) (*User, error) {
-	var user User
-	err := r.db.Where("android_id = ?", androidID).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("user with Android ID %s not found", androidID)
-		}
-		return nil, err
-	}
-	return &user, nil
-}
-
-func (r *UserRepository) UpdateUser(user *User) error {
-	return r.db.Save(user).Error
-}
-
-func (r *UserRepository) DeleteUser(id uint) error {
-	return r.db.Delete(&User{}, id).Error
-}
-
-func (r *UserRepository) GetAllUsers(limit, offset int) ([]User, error) {
-	var users []User
-	err := r.db.Limit(limit).Offset(offset).Find(&users).Error
-	return users, err
-}
-
-// Fixed UserExists method to handle Android ID and nullable email
-func (r *UserRepository) UserExists(email, androidID string) (*User, bool) {
-	var user User
-	var err error
-	
-	if androidID != "" {
-		err = r.db.Where("android_id = ?", androidID).First(&user).Error
-	} else if email != "" {
-		err = r.db.Where("email = ?", email).First(&user).Error
-	} else {
-		return nil, false
-	}
-	
-	if err != nil {
-		return nil, false
-	}
-	return &user, true
-}
-
-func (r *UserRepository) CreateOrUpdateUser(user *User) (*User, error) {
-	// Check if user exists by Google ID, Android ID, or email
-	var existingUser User
-	var err error
-	
-	if user.GoogleID != nil && *user.GoogleID != "" {
-		err = r.db.Where("google_id = ?", *user.GoogleID).First(&existingUser).Error
-	} else if user.AndroidID != nil && *user.AndroidID != "" {
-		err = r.db.Where("android_id = ?", *user.AndroidID).First(&existingUser).Error
-	} else if user.Email != nil && *user.Email != "" {
-		err = r.db.Where("email = ?", *user.Email).First(&existingUser).Error
-	}
-	
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, err
-	}
-	
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// User doesn't exist, create new
-		if err := r.db.Create(user).Error; err != nil {
-			return nil, err
-		}
-		return user, nil
-	}
-	
-	// User exists, update
-	existingUser.Username = user.Username
-	if user.Email != nil && *user.Email != "" {
-		existingUser.Email = user.Email
-	}
-	if user.AndroidID != nil {
-		existingUser.AndroidID = user.AndroidID
-	}
-	if user.GoogleID != nil {
-		existingUser.GoogleID = user.GoogleID
-	}
-	if user.PasswordHash != nil {
-		existingUser.PasswordHash = user.PasswordHash
-	}
-	
-	if err := r.db.Save(&existingUser).Error; err != nil {
-		return nil, err
-	}
-	
-	return &existingUser, nil
-}
\ No newline at end of file
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// CreateUser stores a new user. It returns ErrUsernameTaken if another
+// account already has the username.
+func (r *UserRepository) CreateUser(user *User) error {
+	return usernameConflict(r.db.Create(user).Error)
+}
+
+// CreateUserWithUniqueUsername stores a new user whose username the server
+// derived, appending a number to it if another account already has it.
+func (r *UserRepository) CreateUserWithUniqueUsername(user *User) error {
+	username, err := freeUsername(r.db, user.Username)
+	if err != nil {
+		return err
+	}
+	user.Username = username
+	return r.CreateUser(user)
+}
+
+func (r *UserRepository) GetUserByID(id uint) (*User, error) {
+	var user User
+	err := r.db.Preload("Identities").First(&user, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByEmail returns the user with email, ignoring case.
+func (r *UserRepository) GetUserByEmail(email string) (*User, error) {
+	var user User
+	err := whereEmail(r.db.Preload("Identities"), email).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user with email %s not found", email)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UsernameTaken reports whether another user already has username, ignoring
+// case. Deleted accounts keep their username until they are purged.
+func (r *UserRepository) UsernameTaken(username string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&User{}).Unscoped().
+		Where("LOWER(username) = LOWER(?) AND id <> ?", username, exceptID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateUser saves the user's own columns. Identities are changed through
+// LinkIdentity and UnlinkIdentity. It returns ErrUsernameTaken if another
+// account already has the username.
+func (r *UserRepository) UpdateUser(user *User) error {
+	return usernameConflict(r.db.Omit(clause.Associations).Save(user).Error)
+}
+
+// DeleteUser permanently removes the user and everything keyed by their ID.
+// See PurgeUser.
+func (r *UserRepository) DeleteUser(id uint) error {
+	return r.PurgeUser(id)
+}
+
+func (r *UserRepository) UpdateUserRole(id uint, role Role) error {
+	result := r.db.Model(&User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user with ID %d not found", id)
+	}
+	return nil
+}
+
+// PromoteUsersByEmail grants role to every existing user whose email is
+// listed and verified.
+func (r *UserRepository) PromoteUsersByEmail(emails []string, role Role) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	result := r.db.Model(&User{}).
+		Where("LOWER(email) IN ? AND email_verified_at IS NOT NULL AND role <> ?", emails, role).
+		Update("role", role)
+	return result.RowsAffected, result.Error
+}
+
+func (r *UserRepository) GetAllUsers(limit, offset int) ([]User, error) {
+	var users []User
+	err := r.db.Limit(limit).Offset(offset).Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *UserRepository) CreateSession(session *Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *UserRepository) GetSessionByID(id uint) (*Session, error) {
+	var session Session
+	err := r.db.First(&session, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *UserRepository) GetSessionByFamilyID(familyID string) (*Session, error) {
+	var session Session
+	err := r.db.Where("family_id = ?", familyID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveSessions lists the user's sessions that still hold a usable
+// refresh token, most recently seen first.
+func (r *UserRepository) GetActiveSessions(userID uint) ([]Session, error) {
+	var sessions []Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("EXISTS (?)", r.db.Model(&RefreshToken{}).Select("1").
+			Where("refresh_tokens.family_id = sessions.family_id").
+			Where("refresh_tokens.revoked_at IS NULL AND refresh_tokens.rotated_at IS NULL").
+			Where("refresh_tokens.expires_at > ?", time.Now().UTC())).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *UserRepository) UpdateSession(session *Session) error {
+	return r.db.Save(session).Error
+}
+
+// TouchSession records that the session was just used from ip.
+func (r *UserRepository) TouchSession(id uint, ip string) error {
+	now := time.Now().UTC()
+	return r.db.Model(&Session{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_ip": ip, "last_seen_at": now, "updated_at": now}).Error
+}
+
+// RevokeSession ends the session and revokes its refresh tokens.
+func (r *UserRepository) RevokeSession(session *Session) error {
+	return r.RevokeRefreshTokenFamily(session.FamilyID)
+}
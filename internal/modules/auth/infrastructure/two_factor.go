@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TwoFactorThrottleKey is the login throttle identifier that counts wrong
+// second-factor codes for a user.
+func TwoFactorThrottleKey(userID uint) string {
+	return fmt.Sprintf("2fa:%d", userID)
+}
+
+// SetPendingTOTP stores a new TOTP secret that takes effect once confirmed.
+// Any unconfirmed secret is replaced.
+func (r *UserRepository) SetPendingTOTP(userID uint, secret string) error {
+	return r.db.Model(&User{}).Where("id = ? AND totp_enabled_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"totp_secret": secret, "totp_last_step": 0, "updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// EnableTOTP turns on two-factor login with the pending secret and replaces
+// the user's recovery codes, in one transaction.
+func (r *UserRepository) EnableTOTP(userID uint, recoveryCodeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		if err := tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_enabled_at": now, "updated_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	})
+}
+
+// DisableTOTP removes the secret and every recovery code.
+func (r *UserRepository) DisableTOTP(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"totp_secret": nil, "totp_enabled_at": nil, "totp_last_step": 0, "updated_at": time.Now().UTC(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+	})
+}
+
+// UseTOTPStep records step as the last accepted TOTP time step. It returns
+// false if that step or a later one was already used, which stops a code
+// from being replayed within its validity window.
+func (r *UserRepository) UseTOTPStep(userID uint, step int64) (bool, error) {
+	result := r.db.Model(&User{}).Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores new ones.
+func (r *UserRepository) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, hashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, hashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	codes := make([]RecoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It returns false if
+// the code does not exist or was already used.
+func (r *UserRepository) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	result := r.db.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now().UTC())
+	return result.RowsAffected == 1, result.Error
+}
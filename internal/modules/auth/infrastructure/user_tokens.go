@@ -0,0 +1,98 @@
+package infrastructure
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUserTokenInvalid is returned for a token that does not exist, has
+// expired or was already used.
+var ErrUserTokenInvalid = errors.New("token is invalid or has expired")
+
+// CreateUserToken stores a new token and invalidates any outstanding token
+// the user holds for the same purpose, so only the latest link works.
+func (r *UserRepository) CreateUserToken(token *UserToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now().UTC()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetUserToken returns the token if it can still be redeemed, without
+// redeeming it.
+func (r *UserRepository) GetUserToken(hash string, purpose TokenPurpose) (*UserToken, error) {
+	var token UserToken
+	err := r.db.Where("token_hash = ? AND purpose = ?", hash, purpose).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.UsedAt != nil || time.Now().UTC().After(token.ExpiresAt) {
+		return nil, ErrUserTokenInvalid
+	}
+	return &token, nil
+}
+
+// ConsumeUserToken marks the token as used and returns it. The conditional
+// update guarantees a token can only be redeemed once, even concurrently.
+func (r *UserRepository) ConsumeUserToken(hash string, purpose TokenPurpose) (*UserToken, error) {
+	var token UserToken
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("token_hash = ? AND purpose = ?", hash, purpose).First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if token.UsedAt != nil || now.After(token.ExpiresAt) {
+			return ErrUserTokenInvalid
+		}
+		result := tx.Model(&UserToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserTokenInvalid
+		}
+		token.UsedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteExpiredUserTokens removes tokens that expired before cutoff.
+func (r *UserRepository) DeleteExpiredUserTokens(cutoff time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", cutoff).Delete(&UserToken{})
+	return result.RowsAffected, result.Error
+}
+
+// MarkEmailVerified records that the user proved ownership of email. It does
+// nothing if the user has since changed their address.
+func (r *UserRepository) MarkEmailVerified(userID uint, email string) (bool, error) {
+	result := r.db.Model(&User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Update("email_verified_at", time.Now().UTC())
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdatePassword replaces the user's password hash.
+func (r *UserRepository) UpdatePassword(userID uint, passwordHash string) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).
+		Update("password_hash", passwordHash).Error
+}
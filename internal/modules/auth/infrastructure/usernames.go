@@ -0,0 +1,80 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// UsernameMaxLength is the longest username an account can have.
+const UsernameMaxLength = 32
+
+// usernameIndex makes usernames unique regardless of case.
+const usernameIndex = "idx_users_username_lower"
+
+// ErrUsernameTaken is returned when another account already has the
+// username, ignoring case.
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// EnsureUsernameIndex renames accounts whose username differs from an older
+// account's only by case, then builds the unique index on LOWER(username).
+// Renamed accounts get their ID appended. It must run after AutoMigrate.
+func EnsureUsernameIndex(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&User{}, usernameIndex) {
+		return nil
+	}
+	err := db.Exec(`
+		UPDATE users u SET username = LEFT(u.username, 254 - LENGTH(u.id::text)) || '_' || u.id
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY LOWER(username) ORDER BY id) AS rank
+			FROM users
+		) ranked
+		WHERE u.id = ranked.id AND ranked.rank > 1`).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX " + usernameIndex + " ON users (LOWER(username))").Error
+}
+
+// usernameConflict turns a violation of the username index into
+// ErrUsernameTaken and returns any other error unchanged.
+func usernameConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == usernameIndex {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+// usernameFree reports whether no account has username, ignoring case.
+func usernameFree(tx *gorm.DB, username string) (bool, error) {
+	var count int64
+	err := tx.Model(&User{}).Unscoped().Where("LOWER(username) = LOWER(?)", username).Count(&count).Error
+	return count == 0, err
+}
+
+// freeUsername returns base if no account has it, else base with a random
+// number appended, cut so the result stays within UsernameMaxLength. It is
+// for usernames the server derived, which the user never chose and so may
+// change freely.
+func freeUsername(tx *gorm.DB, base string) (string, error) {
+	candidate := base
+	for range 10 {
+		free, err := usernameFree(tx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if free {
+			return candidate, nil
+		}
+		suffix := fmt.Sprint(1000 + rand.IntN(9000))
+		if len(base)+len(suffix) > UsernameMaxLength {
+			base = base[:UsernameMaxLength-len(suffix)]
+		}
+		candidate = base + suffix
+	}
+	return "", ErrUsernameTaken
+}
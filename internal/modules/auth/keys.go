@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const minRSAKeyBits = 2048
+
+// jwtKey is one key known to the key store. Verify-only keys (retired keys
+// kept around during a rotation) have no private half.
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// keyStore holds the key used to sign new access tokens and every key whose
+// tokens are still accepted.
+//
+// Keys are PEM files in JWT_KEYS_DIR, named <kid>.pem. A file holding a
+// private key (RSA or Ed25519, PKCS#1 or PKCS#8) can sign; a file holding only
+// a public key is accepted for verification. JWT_SIGNING_KID selects the
+// signing key and may be omitted when the directory holds exactly one private
+// key. To rotate, add the new key, point JWT_SIGNING_KID at it, and replace the
+// old private key with its public half until the old tokens have expired.
+//
+// Without JWT_KEYS_DIR the store falls back to HS256 with JWT_SECRET; such
+// tokens cannot be verified by other services and nothing is published in
+// the JWKS. One of the two must be set.
+type keyStore struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+}
+
+func loadKeyStore() (*keyStore, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			// An empty HMAC key would let anyone mint valid tokens.
+			return nil, errors.New("neither JWT_KEYS_DIR nor JWT_SECRET is set")
+		}
+		return newHMACKeyStore([]byte(secret)), nil
+	}
+	return loadKeyStoreFromDir(dir, os.Getenv("JWT_SIGNING_KID"))
+}
+
+func newHMACKeyStore(secret []byte) *keyStore {
+	key := &jwtKey{method: jwt.SigningMethodHS256, private: secret, public: secret}
+	return &keyStore{signing: key, keys: map[string]*jwtKey{"": key}}
+}
+
+func loadKeyStoreFromDir(dir, signingKID string) (*keyStore, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	store := &keyStore{keys: make(map[string]*jwtKey)}
+	var privateKIDs []string
+	for _, path := range paths {
+		kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub")
+		if _, exists := store.keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate key ID %q in %s", kid, dir)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseJWTKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", path, err)
+		}
+		store.keys[kid] = key
+		if key.private != nil {
+			privateKIDs = append(privateKIDs, kid)
+		}
+	}
+
+	switch {
+	case signingKID != "":
+		key, ok := store.keys[signingKID]
+		if !ok || key.private == nil {
+			return nil, fmt.Errorf("signing key %q not found or has no private key in %s", signingKID, dir)
+		}
+		store.signing = key
+	case len(privateKIDs) == 1:
+		store.signing = store.keys[privateKIDs[0]]
+	default:
+		return nil, fmt.Errorf("JWT_SIGNING_KID must name one of the %d private keys in %s", len(privateKIDs), dir)
+	}
+
+	log.Printf("Loaded %d JWT key(s), signing with %q (%s)", len(store.keys), store.signing.kid, store.signing.method.Alg())
+	return store, nil
+}
+
+func parseJWTKey(kid string, data []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, public: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// sign signs claims with the active key and stamps its kid in the header.
+func (ks *keyStore) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.kid != "" {
+		token.Header["kid"] = ks.signing.kid
+	}
+	return token.SignedString(ks.signing.private)
+}
+
+// keyFunc resolves the verification key from the token's kid and refuses any
+// algorithm other than the one that key was loaded for.
+func (ks *keyStore) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", t.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwks returns every asymmetric verification key, sorted by kid.
+func (ks *keyStore) jwks() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// JWKSHandler godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys other services can use to verify PlantGo access tokens
+// @Tags         Auth
+// @Produce      json
+// @Success      200 {object} JWKS
+// @Router       /.well-known/jwks.json [get]
+func (s *AuthService) JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, s.keys.jwks())
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyStoreRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2024-rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2025-ed.pem", "PRIVATE KEY", der)
+
+	if _, err := loadKeyStoreFromDir(dir, ""); err == nil {
+		t.Fatal("expected an error when several private keys exist and no signing kid is set")
+	}
+
+	// Tokens signed with the old key...
+	old := &AuthService{keys: mustLoadKeyStore(t, dir, "2024-rsa")}
+	oldToken, err := old.generateSessionJWT(infrastructure.User{ID: 1, Username: "old"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ...stay valid once the new key becomes the signing key, even after the
+	// old private key is replaced by its public half.
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(dir, "2024-rsa.pem"))
+	writePEM(t, dir, "2024-rsa.pub.pem", "PUBLIC KEY", pubDER)
+
+	current := &AuthService{keys: mustLoadKeyStore(t, dir, "2025-ed")}
+	newToken, err := current.generateSessionJWT(infrastructure.User{ID: 2, Username: "new"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"old key": oldToken, "new key": newToken} {
+		if _, err := current.parseJWT(token); err != nil {
+			t.Errorf("%s: expected token to verify, got %v", name, err)
+		}
+	}
+
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(newToken, &Claims{})
+	if parsed.Header["kid"] != "2025-ed" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("unexpected header %v", parsed.Header)
+	}
+
+	if _, err := loadKeyStoreFromDir(dir, "2024-rsa"); err == nil {
+		t.Error("expected an error when the signing kid has no private key")
+	}
+
+	jwks := current.keys.jwks()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(jwks.Keys))
+	}
+	if jwks.Keys[0].Kid != "2024-rsa" || jwks.Keys[0].Kty != "RSA" || jwks.Keys[0].E != "AQAB" {
+		t.Errorf("unexpected RSA JWK %+v", jwks.Keys[0])
+	}
+	if jwks.Keys[1].Kid != "2025-ed" || jwks.Keys[1].Kty != "OKP" || jwks.Keys[1].Crv != "Ed25519" {
+		t.Errorf("unexpected Ed25519 JWK %+v", jwks.Keys[1])
+	}
+}
+
+func TestKeyStoreRejectsAlgorithmConfusion(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "main.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	s := &AuthService{keys: mustLoadKeyStore(t, dir, "")}
+
+	// An HS256 token keyed with the RSA public key must not verify.
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{Issuer: jwtIssuer(), Subject: "1"}})
+	forged.Header["kid"] = "main"
+	forgedToken, err := forged.SignedString(pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.parseJWT(forgedToken); err == nil {
+		t.Error("expected HS256 token to be rejected by an RS256 key")
+	}
+
+	// Tokens without a kid are rejected once asymmetric keys are configured.
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{RegisteredClaims: jwt.RegisteredClaims{Issuer: jwtIssuer(), Subject: "1"}})
+	unsignedToken, _ := unsigned.SignedString(rsaKey)
+	if _, err := s.parseJWT(unsignedToken); err == nil {
+		t.Error("expected token without kid to be rejected")
+	}
+}
+
+func mustLoadKeyStore(t *testing.T, dir, kid string) *keyStore {
+	t.Helper()
+	ks, err := loadKeyStoreFromDir(dir, kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks
+}
+
+func TestLoadKeyStoreRequiresKey(t *testing.T) {
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_SECRET", "")
+	if _, err := loadKeyStore(); err == nil {
+		t.Fatal("expected an error without JWT_KEYS_DIR or JWT_SECRET")
+	}
+
+	t.Setenv("JWT_SECRET", "s3cret")
+	ks, err := loadKeyStore()
+	if err != nil {
+		t.Fatalf("loadKeyStore with JWT_SECRET: %v", err)
+	}
+	if ks.signing.method != jwt.SigningMethodHS256 {
+		t.Errorf("expected HS256, got %s", ks.signing.method.Alg())
+	}
+}
@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// currentUser loads the authenticated user, writing an error response and
+// returning false if that is not possible.
+func (s *AuthService) currentUser(c *gin.Context) (*infrastructure.User, bool) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+// mergeGuestInto folds a guest's progress into the account that already owns
+// the identity being linked, then signs the caller in as that account. If the
+// account has two-factor login enabled, nothing is merged until the second
+// factor is verified at /auth/2fa/verify.
+func (s *AuthService) mergeGuestInto(c *gin.Context, guest, target *infrastructure.User) {
+	if target.TwoFactorEnabled() {
+		s.respondWithChallenge(c, target, guest.ID, "")
+		return
+	}
+	merged, ok := s.mergeGuest(c, guest, target)
+	if !ok {
+		return
+	}
+	s.respondWithTokens(c, http.StatusOK, merged)
+}
+
+// mergeGuest merges guest into target and returns the reloaded target,
+// writing an error response and returning false if that fails.
+func (s *AuthService) mergeGuest(c *gin.Context, guest, target *infrastructure.User) (*infrastructure.User, bool) {
+	if err := s.userRepo.MergeUsers(guest.ID, target.ID); err != nil {
+		log.Printf("Failed to merge guest %d into user %d: %v", guest.ID, target.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge accounts"})
+		return nil, false
+	}
+	merged, err := s.userRepo.GetUserByID(target.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load merged account"})
+		return nil, false
+	}
+	return merged, true
+}
+
+// LinkEmailHandler godoc
+// @Summary      Link an email and password
+// @Description  Adds an email and password to the caller's account so a guest keeps their progress. If the email already belongs to another account, the password for that account must be given and the guest is merged into it. Accounts with two-factor login get a dto.TwoFactorChallengeResponse instead of tokens, and the merge waits until it is completed at /auth/2fa/verify.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.LinkEmailRequest true "Email credentials"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/link/email [post]
+func (s *AuthService) LinkEmailHandler(c *gin.Context) {
+	var req dto.LinkEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.Email != nil && *user.Email != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account already has an email address"})
+		return
+	}
+
+	if existing, err := s.userRepo.GetUserByEmail(req.Email); err == nil && existing.ID != user.ID {
+		if !user.IsGuest() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email is already used by another account"})
+			return
+		}
+		// Proving ownership of the other account is a password login, so it
+		// is throttled like one.
+		if !s.checkLoginAllowed(c, "link", req.Email) {
+			return
+		}
+		if existing.PasswordHash == nil || !verifyPassword(req.Password, *existing.PasswordHash) {
+			s.recordLoginFailure(c, "link", req.Email, &existing.ID, "wrong_password")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+		s.recordLoginSuccess(req.Email)
+		s.mergeGuestInto(c, user, existing)
+		return
+	}
+
+	username := user.Username
+	if strings.TrimSpace(req.Username) != "" {
+		requested, err := validateUsername(req.Username)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !strings.EqualFold(requested, username) && !s.checkUsernameAvailable(c, requested, user.ID) {
+			return
+		}
+		username = requested
+	}
+	if err := s.passwords.validate(req.Password, username, req.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	email := req.Email
+	user.Email = &email
+	user.PasswordHash = &hashedPassword
+	user.Username = username
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		usernameSaveFailed(c, err, "Failed to link email")
+		return
+	}
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	s.respondWithLogin(c, user)
+}
+
+// LinkGoogleHandler godoc
+// @Summary      Link a Google account
+// @Description  Attaches the Google account from a verified ID token to the caller's account. If that Google account already has a PlantGo account, a guest caller is merged into it. Accounts with two-factor login get a dto.TwoFactorChallengeResponse instead of tokens, and the merge waits until it is completed at /auth/2fa/verify.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.GoogleIDTokenRequest true "Google ID token"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/link/google [post]
+func (s *AuthService) LinkGoogleHandler(c *gin.Context) {
+	var req dto.GoogleIDTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := s.googleIDTokens.verify(c.Request.Context(), req.IDToken)
+	if err != nil {
+		log.Printf("Rejected Google ID token: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Google ID token"})
+		return
+	}
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Google account email is not verified"})
+		return
+	}
+
+	email := claims.Email
+	s.linkExternalIdentity(c, &infrastructure.UserIdentity{
+		Provider:      infrastructure.ProviderGoogle,
+		Subject:       claims.Subject,
+		Email:         &email,
+		EmailVerified: true,
+	}, "Google")
+}
+
+// identitySignInFailed responds to an error from signing in with an external
+// identity.
+func identitySignInFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrEmailUnverified):
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists; sign in with your password and verify your email first"})
+	case errors.Is(err, infrastructure.ErrIdentityTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Identity is linked to another account"})
+	case errors.Is(err, infrastructure.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+	default:
+		log.Printf("Failed to sign in with external identity: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user"})
+	}
+}
+
+// linkExternalIdentity attaches a verified identity to the caller's account
+// and signs the caller in again. If the identity already has a PlantGo
+// account, a guest caller is merged into it. A verified email from the
+// provider is taken over by an account without one, unless another account
+// uses it. label names the provider in error messages.
+func (s *AuthService) linkExternalIdentity(c *gin.Context, identity *infrastructure.UserIdentity, label string) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if linked, ok := user.Identity(identity.Provider); ok {
+		if linked.Subject == identity.Subject {
+			s.respondWithLogin(c, user)
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is already linked to a different " + label + " account"})
+		return
+	}
+
+	if existing, err := s.userRepo.GetUserByIdentity(identity.Provider, identity.Subject); err == nil && existing.ID != user.ID {
+		if !user.IsGuest() {
+			c.JSON(http.StatusConflict, gin.H{"error": label + " account is already linked to another account"})
+			return
+		}
+		s.mergeGuestInto(c, user, existing)
+		return
+	}
+
+	identity.UserID = user.ID
+	if err := s.userRepo.LinkIdentity(identity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link " + label + " account"})
+		return
+	}
+	if (user.Email == nil || *user.Email == "") && identity.Email != nil && identity.EmailVerified {
+		// Only take the provider's email if no other account is using it.
+		if _, err := s.userRepo.GetUserByEmail(*identity.Email); err != nil {
+			email := *identity.Email
+			verifiedAt := time.Now().UTC()
+			user.Email = &email
+			user.EmailVerifiedAt = &verifiedAt
+			if err := s.userRepo.UpdateUser(user); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link " + label + " account"})
+				return
+			}
+			s.promoteBootstrapAdmin(user)
+		}
+	}
+	user.Identities = append(user.Identities, *identity)
+
+	s.respondWithLogin(c, user)
+}
+
+// canUnlink reports whether the user can still sign in after losing their
+// identity for provider: with a password, another external identity, or a
+// device that has a secret.
+func canUnlink(user *infrastructure.User, provider infrastructure.IdentityProvider) bool {
+	if user.PasswordHash != nil && user.Email != nil && *user.Email != "" {
+		return true
+	}
+	for _, identity := range user.Identities {
+		if identity.Provider == provider {
+			continue
+		}
+		if identity.Provider != infrastructure.ProviderAndroid || user.DeviceSecretHash != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ListIdentitiesHandler godoc
+// @Summary      List linked identities
+// @Description  Lists the device and external accounts the caller can sign in with
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {array} infrastructure.UserIdentity
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/identities [get]
+func (s *AuthService) ListIdentitiesHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	identities, err := s.userRepo.GetUserIdentities(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load identities"})
+		return
+	}
+	c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentityHandler godoc
+// @Summary      Unlink an identity
+// @Description  Detaches the caller's identity for a provider (e.g. google, android). Refused if the account would be left without a way to sign in.
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        provider path string true "Identity provider"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/identities/{provider} [delete]
+func (s *AuthService) UnlinkIdentityHandler(c *gin.Context) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+
+	provider := infrastructure.IdentityProvider(strings.ToLower(c.Param("provider")))
+	if _, linked := user.Identity(provider); !linked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity not linked"})
+		return
+	}
+	if !canUnlink(user, provider) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Link another sign-in method before removing this one"})
+		return
+	}
+
+	if err := s.userRepo.UnlinkIdentity(user.ID, provider); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink identity"})
+		return
+	}
+	// The device secret only proves the device identity that was just removed.
+	if provider == infrastructure.ProviderAndroid && user.DeviceSecretHash != nil {
+		user.DeviceSecretHash = nil
+		if err := s.userRepo.UpdateUser(user); err != nil {
+			log.Printf("Failed to clear device secret for user %d: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Identity unlinked"})
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// twoFactorUser returns a user with two-factor login enabled.
+func twoFactorUser(id uint) *infrastructure.User {
+	secret, enabledAt := "JBSWY3DPEHPK3PXP", time.Now()
+	return &infrastructure.User{ID: id, Username: "fern", TOTPSecret: &secret, TOTPEnabledAt: &enabledAt}
+}
+
+// decodeChallenge checks that rr holds a two-factor challenge and not tokens.
+func decodeChallenge(t *testing.T, rr *httptest.ResponseRecorder) dto.TwoFactorChallengeResponse {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["token"]; ok || rr.Code != http.StatusOK {
+		t.Fatalf("expected a challenge without tokens, got %d %s", rr.Code, rr.Body)
+	}
+	var challenge dto.TwoFactorChallengeResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &challenge); err != nil {
+		t.Fatal(err)
+	}
+	if !challenge.TwoFactorRequired || challenge.ChallengeToken == "" {
+		t.Fatalf("expected a challenge, got %s", rr.Body)
+	}
+	return challenge
+}
+
+func TestMergeGuestIntoTwoFactorAccountWaitsForSecondFactor(t *testing.T) {
+	// The service has no repository, so merging or issuing tokens would panic.
+	s := newTestAuthService()
+	gin.SetMode(gin.TestMode)
+	rr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rr)
+
+	s.mergeGuestInto(c, &infrastructure.User{ID: 7, Username: "guest"}, twoFactorUser(42))
+
+	challenge := decodeChallenge(t, rr)
+	userID, guestID, err := s.parseTwoFactorChallenge(challenge.ChallengeToken)
+	if err != nil || userID != 42 || guestID != 7 {
+		t.Errorf("expected a challenge for user 42 carrying guest 7, got %d %d %v", userID, guestID, err)
+	}
+}
+
+func TestCanUnlink(t *testing.T) {
+	email, hash := "fern@example.com", "hash"
+	google := infrastructure.UserIdentity{Provider: infrastructure.ProviderGoogle, Subject: "g-1"}
+	device := infrastructure.UserIdentity{Provider: infrastructure.ProviderAndroid, Subject: "a-1"}
+
+	tests := []struct {
+		name     string
+		user     infrastructure.User
+		provider infrastructure.IdentityProvider
+		want     bool
+	}{
+		{"password remains", infrastructure.User{Email: &email, PasswordHash: &hash, Identities: []infrastructure.UserIdentity{google}}, infrastructure.ProviderGoogle, true},
+		{"only google", infrastructure.User{Email: &email, Identities: []infrastructure.UserIdentity{google}}, infrastructure.ProviderGoogle, false},
+		{"device with secret remains", infrastructure.User{DeviceSecretHash: &hash, Identities: []infrastructure.UserIdentity{google, device}}, infrastructure.ProviderGoogle, true},
+		{"device without secret", infrastructure.User{Identities: []infrastructure.UserIdentity{google, device}}, infrastructure.ProviderGoogle, false},
+		{"google remains", infrastructure.User{DeviceSecretHash: &hash, Identities: []infrastructure.UserIdentity{google, device}}, infrastructure.ProviderAndroid, true},
+		{"guest device", infrastructure.User{DeviceSecretHash: &hash, Identities: []infrastructure.UserIdentity{device}}, infrastructure.ProviderAndroid, false},
+	}
+	for _, tc := range tests {
+		if got := canUnlink(&tc.user, tc.provider); got != tc.want {
+			t.Errorf("%s: canUnlink = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
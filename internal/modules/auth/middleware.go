@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// Context keys populated by AuthMiddleware.
+const (
+	ContextUserID   = "userID"
+	ContextUsername = "username"
+	ContextEmail    = "email"
+	ContextRole     = "role"
+	// ContextSessionID is only set for tokens minted for a device session.
+	ContextSessionID = "sessionID"
+	// ContextTwoFactor is true when the session's login passed a second factor.
+	ContextTwoFactor = "twoFactor"
+)
+
+// AuthMiddleware validates the Bearer access token on the request and stores
+// the caller's identity in the gin context. Requests without a valid token are
+// rejected with 401 and never reach the handler.
+func (s *AuthService) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			abortUnauthorized(c, "Missing authorization header")
+			return
+		}
+
+		scheme, tokenString, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(tokenString) == "" {
+			abortUnauthorized(c, "Authorization header must use the Bearer scheme")
+			return
+		}
+
+		claims, err := s.parseJWT(strings.TrimSpace(tokenString))
+		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				abortUnauthorized(c, "Token has expired")
+				return
+			}
+			abortUnauthorized(c, "Invalid token")
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			abortUnauthorized(c, "Invalid token")
+			return
+		}
+
+		if claims.SessionID != 0 {
+			if !s.sessionActive(c, userID, claims.SessionID) {
+				abortUnauthorized(c, "Session has been revoked")
+				return
+			}
+			c.Set(ContextSessionID, claims.SessionID)
+		}
+
+		c.Set(ContextUserID, userID)
+		c.Set(ContextUsername, claims.Username)
+		c.Set(ContextEmail, claims.Email)
+		c.Set(ContextRole, claims.Role)
+		c.Set(ContextTwoFactor, claims.TwoFactor)
+		c.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated user's ID set by AuthMiddleware.
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get(ContextUserID)
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(uint)
+	return userID, ok && userID != 0
+}
+
+// CurrentSessionID returns the session the caller's access token belongs to.
+func CurrentSessionID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get(ContextSessionID)
+	if !exists {
+		return 0, false
+	}
+	sessionID, ok := value.(uint)
+	return sessionID, ok && sessionID != 0
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="plantgo"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
+
+// OptionalAuthMiddleware populates the caller's identity when a valid Bearer
+// token is present but lets anonymous requests through. A token that is
+// present but invalid is still rejected.
+func (s *AuthService) OptionalAuthMiddleware() gin.HandlerFunc {
+	required := s.AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
+
+// RequireRole rejects callers whose role is not one of roles. Callers whose
+// role is listed in TWO_FACTOR_REQUIRED_ROLES must also have passed a second
+// factor when they logged in. It must run after AuthMiddleware.
+func RequireRole(roles ...infrastructure.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := CurrentRole(c)
+		if !ok {
+			abortUnauthorized(c, "User not authenticated")
+			return
+		}
+		for _, allowed := range roles {
+			if role != allowed {
+				continue
+			}
+			if twoFactorRequired(role) && !c.GetBool(ContextTwoFactor) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error":               "Two-factor authentication is required for this role; enable it and sign in again",
+					"two_factor_required": true,
+				})
+				return
+			}
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	}
+}
+
+// CurrentRole returns the authenticated user's role set by AuthMiddleware.
+// Tokens issued before roles existed are treated as players.
+func CurrentRole(c *gin.Context) (infrastructure.Role, bool) {
+	if _, ok := CurrentUserID(c); !ok {
+		return "", false
+	}
+	role, _ := c.Get(ContextRole)
+	if r, ok := role.(infrastructure.Role); ok && r != "" {
+		return r, true
+	}
+	return infrastructure.RolePlayer, true
+}
+
+var (
+	ErrNotAuthenticated = errors.New("user not authenticated")
+	ErrForbidden        = errors.New("access to another user's resources is forbidden")
+	ErrInvalidUserID    = errors.New("invalid user ID")
+)
+
+// ActingUserID returns the authenticated caller's ID. A non-zero requestedID,
+// taken from a legacy path parameter, query or request body, must match the
+// caller; otherwise ErrForbidden is returned.
+func ActingUserID(c *gin.Context, requestedID uint) (uint, error) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		return 0, ErrNotAuthenticated
+	}
+	if requestedID != 0 && requestedID != userID {
+		return 0, ErrForbidden
+	}
+	return userID, nil
+}
+
+// ActingUserFromPath is ActingUserID for routes that may carry the legacy
+// :userId path parameter. Routes under /me have none and act on the caller.
+func ActingUserFromPath(c *gin.Context) (uint, error) {
+	var requestedID uint
+	if userIDStr := c.Param("userId"); userIDStr != "" {
+		parsed, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			return 0, ErrInvalidUserID
+		}
+		requestedID = uint(parsed)
+	}
+	return ActingUserID(c, requestedID)
+}
+
+// ActingUserStatus maps an error from ActingUserID or ActingUserFromPath to
+// the status code and message to respond with.
+func ActingUserStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrInvalidUserID):
+		return http.StatusBadRequest, "Invalid user ID"
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, "Access denied"
+	default:
+		return http.StatusUnauthorized, "User not authenticated"
+	}
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func newTestAuthService() *AuthService {
+	return &AuthService{keys: newHMACKeyStore([]byte("test-secret"))}
+}
+
+func newMiddlewareRouter(s *AuthService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/protected", s.AuthMiddleware(), func(c *gin.Context) {
+		userID, _ := CurrentUserID(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "username": c.GetString(ContextUsername)})
+	})
+	return r
+}
+
+func performRequest(r http.Handler, authorization string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthMiddlewareAcceptsValidToken(t *testing.T) {
+	s := newTestAuthService()
+	r := newMiddlewareRouter(s)
+
+	token, err := s.generateSessionJWT(infrastructure.User{ID: 42, Username: "fern"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := performRequest(r, "Bearer "+token)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	expected := `{"user_id":42,"username":"fern"}`
+	if rr.Body.String() != expected {
+		t.Errorf("unexpected body: got %s want %s", rr.Body.String(), expected)
+	}
+}
+
+func TestAuthMiddlewareRejectsInvalidTokens(t *testing.T) {
+	r := newMiddlewareRouter(newTestAuthService())
+
+	expired := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(42),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	})
+	expiredToken, _ := expired.SignedString([]byte("test-secret"))
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(42),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	forgedToken, _ := forged.SignedString([]byte("other-secret"))
+
+	cases := map[string]struct {
+		header   string
+		expected string
+	}{
+		"missing header":  {"", `{"error":"Missing authorization header"}`},
+		"wrong scheme":    {"Basic abc", `{"error":"Authorization header must use the Bearer scheme"}`},
+		"malformed token": {"Bearer not-a-jwt", `{"error":"Invalid token"}`},
+		"expired token":   {"Bearer " + expiredToken, `{"error":"Token has expired"}`},
+		"bad signature":   {"Bearer " + forgedToken, `{"error":"Invalid token"}`},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rr := performRequest(r, tc.header)
+			if rr.Code != http.StatusUnauthorized {
+				t.Fatalf("expected 401, got %d", rr.Code)
+			}
+			if rr.Body.String() != tc.expected {
+				t.Errorf("unexpected body: got %s want %s", rr.Body.String(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestActingUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if _, err := ActingUserID(c, 0); err != ErrNotAuthenticated {
+		t.Fatalf("expected ErrNotAuthenticated, got %v", err)
+	}
+
+	c.Set(ContextUserID, uint(7))
+	if userID, err := ActingUserID(c, 0); err != nil || userID != 7 {
+		t.Errorf("expected caller 7, got %d (%v)", userID, err)
+	}
+	if userID, err := ActingUserID(c, 7); err != nil || userID != 7 {
+		t.Errorf("expected caller 7 for own resource, got %d (%v)", userID, err)
+	}
+	if _, err := ActingUserID(c, 8); err != ErrForbidden {
+		t.Errorf("expected ErrForbidden for another user's resource, got %v", err)
+	}
+}
+
+func TestActingUserFromPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		param      string
+		wantID     uint
+		wantStatus int
+	}{
+		{"", 7, 0},
+		{"7", 7, 0},
+		{"8", 0, http.StatusForbidden},
+		{"abc", 0, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set(ContextUserID, uint(7))
+		if tc.param != "" {
+			c.Params = gin.Params{{Key: "userId", Value: tc.param}}
+		}
+
+		userID, err := ActingUserFromPath(c)
+		if tc.wantStatus == 0 {
+			if err != nil || userID != tc.wantID {
+				t.Errorf("userId %q: got %d (%v), want %d", tc.param, userID, err, tc.wantID)
+			}
+			continue
+		}
+		if status, _ := ActingUserStatus(err); status != tc.wantStatus {
+			t.Errorf("userId %q: status %d, want %d", tc.param, status, tc.wantStatus)
+		}
+	}
+
+	if status, _ := ActingUserStatus(ErrNotAuthenticated); status != http.StatusUnauthorized {
+		t.Errorf("ErrNotAuthenticated maps to %d, want 401", status)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := RequireRole(infrastructure.RoleContentEditor, infrastructure.RoleAdmin)
+
+	cases := map[string]struct {
+		role     infrastructure.Role
+		expected int
+	}{
+		"admin":          {infrastructure.RoleAdmin, http.StatusOK},
+		"content editor": {infrastructure.RoleContentEditor, http.StatusOK},
+		"player":         {infrastructure.RolePlayer, http.StatusForbidden},
+		"legacy token":   {"", http.StatusForbidden},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rr)
+			c.Set(ContextUserID, uint(1))
+			c.Set(ContextRole, tc.role)
+			handler(c)
+			if !c.IsAborted() {
+				c.Status(http.StatusOK)
+			}
+			if rr.Code != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, rr.Code)
+			}
+		})
+	}
+}
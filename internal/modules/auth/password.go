@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+const (
+	defaultPasswordMinLength = 8
+	// bcrypt ignores everything past 72 bytes, so longer passwords are
+	// rejected rather than silently truncated.
+	maxPasswordBytes = 72
+)
+
+// passwordPolicy decides which new passwords are accepted.
+type passwordPolicy struct {
+	minLength int
+	// breached holds upper-case hex SHA-1 hashes of known leaked passwords.
+	breached map[string]struct{}
+}
+
+// loadPasswordPolicy reads PASSWORD_MIN_LENGTH and PASSWORD_BREACHED_LIST, a
+// file of SHA-1 hashes in the Have I Been Pwned format ("HASH" or
+// "HASH:COUNT", one per line).
+func loadPasswordPolicy() (passwordPolicy, error) {
+	policy := passwordPolicy{minLength: env.Int("PASSWORD_MIN_LENGTH", defaultPasswordMinLength)}
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		breached, err := loadBreachedHashes(path)
+		if err != nil {
+			return policy, err
+		}
+		policy.breached = breached
+		log.Printf("Loaded %d breached password hashes", len(breached))
+	}
+	return policy, nil
+}
+
+func loadBreachedHashes(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	hashes := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if len(hash) != sha1.Size*2 {
+			continue
+		}
+		hashes[strings.ToUpper(hash)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return hashes, nil
+}
+
+func (p passwordPolicy) isBreached(password string) bool {
+	if len(p.breached) == 0 {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	_, found := p.breached[strings.ToUpper(hex.EncodeToString(sum[:]))]
+	return found
+}
+
+// validate returns a user-facing error if password is not acceptable for an
+// account known by identifiers (username, email).
+func (p passwordPolicy) validate(password string, identifiers ...string) error {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return fmt.Errorf("password must be at least %d characters", p.minLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordBytes)
+	}
+
+	lower := strings.ToLower(password)
+	for _, identifier := range identifiers {
+		identifier = strings.ToLower(strings.TrimSpace(identifier))
+		if local, _, found := strings.Cut(identifier, "@"); found {
+			identifier = local
+		}
+		if len(identifier) >= 3 && strings.Contains(lower, identifier) {
+			return errors.New("password must not contain your username or email")
+		}
+	}
+
+	if p.isBreached(password) {
+		return errors.New("password has appeared in a data breach; choose a different one")
+	}
+	return nil
+}
+
+// passwordHashCost is the bcrypt cost for new hashes, PASSWORD_BCRYPT_COST or
+// bcrypt.DefaultCost.
+func passwordHashCost() int {
+	cost := env.Int("PASSWORD_BCRYPT_COST", bcrypt.DefaultCost)
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		log.Printf("Invalid PASSWORD_BCRYPT_COST %d, using %d", cost, bcrypt.DefaultCost)
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+// needsRehash reports whether hash was made with a lower cost than new
+// hashes get.
+func needsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < passwordHashCost()
+}
+
+// upgradePasswordHash rehashes password at the current cost after a
+// successful login with a hash made at a lower one. Failures are logged; the
+// old hash keeps working.
+func (s *AuthService) upgradePasswordHash(user *infrastructure.User, password string) {
+	if user.PasswordHash == nil || !needsRehash(*user.PasswordHash) {
+		return
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hash); err != nil {
+		log.Printf("Failed to store rehashed password for user %d: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = &hash
+}
+
+// ChangePasswordHandler godoc
+// @Summary      Change password
+// @Description  Sets a new password after checking the current one, then signs out every other session. The new password must meet the password policy.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.ChangePasswordRequest true "Current and new password"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/password/change [post]
+func (s *AuthService) ChangePasswordHandler(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.PasswordHash == nil || user.Email == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account has no password; link an email first"})
+		return
+	}
+
+	// Guessing the current password is a password login, so it is throttled
+	// like one.
+	if !s.checkLoginAllowed(c, "password_change", *user.Email) {
+		return
+	}
+	if !verifyPassword(req.CurrentPassword, *user.PasswordHash) {
+		s.recordLoginFailure(c, "password_change", *user.Email, &user.ID, "wrong_password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	s.recordLoginSuccess(*user.Email)
+
+	if req.NewPassword == req.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New password must differ from the current one"})
+		return
+	}
+	if err := s.passwords.validate(req.NewPassword, user.Username, *user.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := hashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	currentSessionID, _ := CurrentSessionID(c)
+	if err := s.revokeOtherSessions(user.ID, currentSessionID); err != nil {
+		log.Printf("Failed to revoke sessions for user %d after password change: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Password changed"})
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	dir := t.TempDir()
+	list := filepath.Join(dir, "breached.txt")
+	// SHA-1 of "password123", in the HIBP "HASH:COUNT" format.
+	if err := os.WriteFile(list, []byte("CBFDAC6008F9CAB4083784CBD1874F76618D2A97:2000000\nnot-a-hash\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PASSWORD_MIN_LENGTH", "10")
+	t.Setenv("PASSWORD_BREACHED_LIST", list)
+
+	policy, err := loadPasswordPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.breached) != 1 {
+		t.Fatalf("expected one breached hash, got %d", len(policy.breached))
+	}
+
+	cases := map[string]bool{
+		"short":                        false,
+		"password123":                  false, // breached
+		"my-fern-gully-pw":             false, // contains the username
+		"Player.One-secret":            false, // contains the email's local part
+		"correct horse battery staple": true,
+		string(make([]byte, 73)):       false, // over the bcrypt limit
+	}
+	for password, ok := range cases {
+		err := policy.validate(password, "fern-gully", "player.one@example.com")
+		if ok && err != nil {
+			t.Errorf("expected %q to be accepted, got %v", password, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("expected %q to be rejected", password)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !needsRehash(string(hash)) {
+		t.Error("a MinCost hash should be upgraded to the default cost")
+	}
+
+	t.Setenv("PASSWORD_BCRYPT_COST", "4")
+	if needsRehash(string(hash)) {
+		t.Error("a hash at the configured cost should be left alone")
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// profaneFragments are rejected anywhere in a word, since they rarely occur
+// inside innocent ones.
+var profaneFragments = []string{
+	"fuck", "shit", "nigger", "nigga", "faggot", "whore", "wanker",
+}
+
+// profaneWords are only rejected as whole words, so names like "Dickens" or
+// "Scunthorpe" still pass.
+var profaneWords = []string{
+	"ass", "asshole", "bastard", "bitch", "cock", "cunt", "dick", "fag", "piss",
+	"porn", "pussy", "rape", "retard", "slut", "tits", "twat",
+}
+
+// leetReplacer undoes the usual character swaps used to dodge filters.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	"@", "a", "$", "s", "!", "i", "|", "l",
+)
+
+// profanityWords splits text into lower-case words after undoing leetspeak.
+// Runs of single letters are joined back up, so "f.u.c.k" is checked as
+// "fuck".
+func profanityWords(text string) []string {
+	normalized := leetReplacer.Replace(strings.ToLower(text))
+	fields := strings.FieldsFunc(normalized, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	var words []string
+	var spelled strings.Builder
+	for _, field := range fields {
+		if len([]rune(field)) == 1 {
+			spelled.WriteString(field)
+			continue
+		}
+		if spelled.Len() > 0 {
+			words = append(words, spelled.String())
+			spelled.Reset()
+		}
+		words = append(words, field)
+	}
+	if spelled.Len() > 0 {
+		words = append(words, spelled.String())
+	}
+	return words
+}
+
+// containsProfanity reports whether text contains a blocked word. Extra whole
+// words can be blocked with the comma separated PROFANITY_BLOCKLIST.
+func containsProfanity(text string) bool {
+	blocked := append(splitEnvList(strings.ToLower(os.Getenv("PROFANITY_BLOCKLIST"))), profaneWords...)
+	for _, word := range profanityWords(text) {
+		if containsString(blocked, word) {
+			return true
+		}
+		for _, fragment := range profaneFragments {
+			if strings.Contains(word, fragment) {
+				return true
+			}
+		}
+	}
+	return false
+}
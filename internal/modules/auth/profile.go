@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+// applyProfileUpdate validates req and copies it onto user. Usernames are
+// checked with validateUsername; uniqueness needs the database and is left
+// to the caller.
+func applyProfileUpdate(user *infrastructure.User, req dto.UpdateProfileRequest) error {
+	if req.Username != nil {
+		username, err := validateUsername(*req.Username)
+		if err != nil {
+			return err
+		}
+		user.Username = username
+	}
+
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			return errors.New("display name contains invalid characters")
+		}
+		if containsProfanity(name) {
+			return errors.New("display name is not allowed")
+		}
+		user.DisplayName = name
+	}
+
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if strings.IndexFunc(bio, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }) >= 0 {
+			return errors.New("bio contains invalid characters")
+		}
+		if containsProfanity(bio) {
+			return errors.New("bio is not allowed")
+		}
+		user.Bio = bio
+	}
+
+	if req.Country != nil {
+		country := strings.ToUpper(strings.TrimSpace(*req.Country))
+		if country != "" && !countryPattern.MatchString(country) {
+			return errors.New("country must be a two-letter ISO 3166-1 code")
+		}
+		user.Country = country
+	}
+
+	if req.PreferredLanguage != nil {
+		language := strings.TrimSpace(*req.PreferredLanguage)
+		if language != "" && !languagePattern.MatchString(language) {
+			return errors.New("preferred language must be a language tag such as \"en\" or \"pt-BR\"")
+		}
+		user.PreferredLanguage = language
+	}
+
+	return nil
+}
+
+// UpdateProfileHandler godoc
+// @Summary      Update my profile
+// @Description  Changes the caller's username, display name, bio, country or preferred language. Omitted fields are left unchanged; an empty string clears an optional field. Usernames are unique regardless of case.
+// @Tags         Profile
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.UpdateProfileRequest true "Fields to change"
+// @Success      200 {object} infrastructure.User
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /me [patch]
+func (s *AuthService) UpdateProfileHandler(c *gin.Context) {
+	var req dto.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+
+	previousUsername := user.Username
+	if err := applyProfileUpdate(user, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !strings.EqualFold(user.Username, previousUsername) && !s.checkUsernameAvailable(c, user.Username, user.ID) {
+		return
+	}
+
+	if err := s.userRepo.UpdateUser(user); err != nil {
+		usernameSaveFailed(c, err, "Failed to update profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
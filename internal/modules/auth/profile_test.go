@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"testing"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestApplyProfileUpdate(t *testing.T) {
+	user := &infrastructure.User{Username: "fern", Bio: "old bio", Country: "NP"}
+	err := applyProfileUpdate(user, dto.UpdateProfileRequest{
+		Username:          strPtr(" Fern_2 "),
+		DisplayName:       strPtr("Fern Gully"),
+		Country:           strPtr("de"),
+		PreferredLanguage: strPtr("pt-BR"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "Fern_2" || user.DisplayName != "Fern Gully" || user.Country != "DE" || user.PreferredLanguage != "pt-BR" {
+		t.Errorf("unexpected profile: %+v", user)
+	}
+	if user.Bio != "old bio" {
+		t.Errorf("omitted bio should be left alone, got %q", user.Bio)
+	}
+
+	if err := applyProfileUpdate(user, dto.UpdateProfileRequest{Country: strPtr("")}); err != nil || user.Country != "" {
+		t.Errorf("empty country should clear it, got %q (%v)", user.Country, err)
+	}
+}
+
+func TestApplyProfileUpdateRejectsInvalidFields(t *testing.T) {
+	cases := map[string]dto.UpdateProfileRequest{
+		"short username":     {Username: strPtr("ab")},
+		"username spaces":    {Username: strPtr("fern gully")},
+		"cleared username":   {Username: strPtr("")},
+		"profane username":   {Username: strPtr("sh1t_player")},
+		"control characters": {DisplayName: strPtr("fern\x00")},
+		"profane bio":        {Bio: strPtr("what a bitch")},
+		"country name":       {Country: strPtr("Nepal")},
+		"language":           {PreferredLanguage: strPtr("english!")},
+	}
+	for name, req := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := applyProfileUpdate(&infrastructure.User{Username: "fern"}, req); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestContainsProfanity(t *testing.T) {
+	t.Setenv("PROFANITY_BLOCKLIST", "weedkiller")
+
+	for _, text := range []string{"FUCK", "f.u.c.k", "xXsh1tXx", "big $lut", "Weedkiller"} {
+		if !containsProfanity(text) {
+			t.Errorf("expected %q to be rejected", text)
+		}
+	}
+	for _, text := range []string{"Dickens", "Cockburn", "bass player", "classic", "Scunthorpe fern", "bass hitter"} {
+		if containsProfanity(text) {
+			t.Errorf("expected %q to be allowed", text)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+var (
+	errRefreshTokenInvalid = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// tokenPair is the set of credentials returned after a successful login or refresh.
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+}
+
+// randomToken returns n bytes of crypto/rand output, base64url encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of an opaque token. Opaque tokens carry
+// enough entropy that a fast hash is sufficient for storage.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRefreshToken creates a refresh token for user in the given family and
+// returns the plaintext value alongside the record to persist.
+func newRefreshToken(user infrastructure.User, familyID string) (string, *infrastructure.RefreshToken, error) {
+	plain, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	return plain, &infrastructure.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(plain),
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL()),
+	}, nil
+}
+
+// issueTokens starts a new session and refresh token family for user on the
+// given device and returns a fresh access/refresh token pair.
+func (s *AuthService) issueTokens(user infrastructure.User, device infrastructure.Session) (*tokenPair, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	device.UserID = user.ID
+	device.FamilyID = familyID
+	if err := s.userRepo.CreateSession(&device); err != nil {
+		return nil, err
+	}
+	plain, record, err := newRefreshToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.CreateRefreshToken(record); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.generateSessionJWT(user, &device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: plain,
+		ExpiresIn:    int64(accessTokenTTL().Seconds()),
+	}, nil
+}
+
+// refreshTokens exchanges a refresh token for a new pair. Presenting a token
+// that was already rotated means it leaked or was replayed, so the whole
+// family is revoked and the legitimate holder has to log in again.
+func (s *AuthService) refreshTokens(presented string, device infrastructure.Session) (*tokenPair, *infrastructure.User, error) {
+	current, err := s.userRepo.GetRefreshTokenByHash(hashToken(presented))
+	if err != nil {
+		return nil, nil, errRefreshTokenInvalid
+	}
+
+	if current.RotatedAt != nil {
+		s.revokeFamilyAfterReuse(current)
+		return nil, nil, errRefreshTokenReused
+	}
+	if current.RevokedAt != nil || time.Now().UTC().After(current.ExpiresAt) {
+		return nil, nil, errRefreshTokenInvalid
+	}
+
+	user, err := s.userRepo.GetUserByID(current.UserID)
+	if err != nil {
+		return nil, nil, errRefreshTokenInvalid
+	}
+
+	plain, next, err := newRefreshToken(*user, current.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.userRepo.RotateRefreshToken(current, next); err != nil {
+		if errors.Is(err, infrastructure.ErrRefreshTokenReused) {
+			s.revokeFamilyAfterReuse(current)
+			return nil, nil, errRefreshTokenReused
+		}
+		return nil, nil, err
+	}
+
+	session, err := s.sessionForFamily(current, device)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, err := s.generateSessionJWT(*user, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &tokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: plain,
+		ExpiresIn:    int64(accessTokenTTL().Seconds()),
+	}, user, nil
+}
+
+func (s *AuthService) revokeFamilyAfterReuse(token *infrastructure.RefreshToken) {
+	log.Printf("Refresh token reuse detected for user %d, revoking token family", token.UserID)
+	if err := s.userRepo.RevokeRefreshTokenFamily(token.FamilyID); err != nil {
+		log.Printf("Failed to revoke refresh token family for user %d: %v", token.UserID, err)
+	}
+}
+
+// respondWithTokens issues a new token pair for user and writes the standard
+// auth response.
+func (s *AuthService) respondWithTokens(c *gin.Context, status int, user *infrastructure.User) {
+	response, err := s.authResponse(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
+		return
+	}
+	c.JSON(status, response)
+}
+
+func (s *AuthService) authResponse(c *gin.Context, user *infrastructure.User) (dto.AuthResponse, error) {
+	return s.authResponseForDevice(user, clientDevice(c))
+}
+
+func (s *AuthService) authResponseForDevice(user *infrastructure.User, device infrastructure.Session) (dto.AuthResponse, error) {
+	tokens, err := s.issueTokens(*user, device)
+	if err != nil {
+		return dto.AuthResponse{}, err
+	}
+	return dto.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         *user,
+	}, nil
+}
+
+// RefreshHandler godoc
+// @Summary      Refresh access token
+// @Description  Exchanges a refresh token for a new access token and a rotated refresh token. Replaying an already used refresh token revokes every token from that login.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RefreshTokenRequest true "Refresh token"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Router       /auth/refresh [post]
+func (s *AuthService) RefreshHandler(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, user, err := s.refreshTokens(req.RefreshToken, clientDevice(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, errRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has already been used; please log in again"})
+		case errors.Is(err, errRefreshTokenInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         *user,
+	})
+}
+
+// LogoutHandler godoc
+// @Summary      Logout
+// @Description  Revokes the given refresh token and every token rotated from the same login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.RefreshTokenRequest true "Refresh token"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/logout [post]
+func (s *AuthService) LogoutHandler(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Unknown tokens are treated as already logged out.
+	if token, err := s.userRepo.GetRefreshTokenByHash(hashToken(req.RefreshToken)); err == nil {
+		if err := s.userRepo.RevokeRefreshTokenFamily(token.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+		if session, err := s.userRepo.GetSessionByFamilyID(token.FamilyID); err == nil {
+			s.deactivateSessionPush(session)
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Logged out"})
+}
+
+// LogoutAllHandler godoc
+// @Summary      Logout from all devices
+// @Description  Revokes every session and refresh token held by the authenticated user and stops push notifications to their devices
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/logout-all [post]
+func (s *AuthService) LogoutAllHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := s.userRepo.RevokeUserRefreshTokens(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+	if err := s.notificationRepo.DeactivateFCMToken(userID); err != nil {
+		log.Printf("Failed to deactivate FCM tokens for user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Logged out from all devices"})
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// bootstrapAdminEmails returns the emails listed in ADMIN_BOOTSTRAP_EMAILS.
+// Accounts with these emails are promoted to admin so a fresh deployment has
+// someone who can manage levels and grant roles to others.
+func bootstrapAdminEmails() []string {
+	var emails []string
+	for _, email := range strings.Split(os.Getenv("ADMIN_BOOTSTRAP_EMAILS"), ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// bootstrapAdmins promotes existing bootstrap accounts at startup. Only
+// verified emails count, so nobody can claim admin by registering one.
+func (s *AuthService) bootstrapAdmins() {
+	emails := bootstrapAdminEmails()
+	if len(emails) == 0 {
+		return
+	}
+	promoted, err := s.userRepo.PromoteUsersByEmail(emails, infrastructure.RoleAdmin)
+	if err != nil {
+		log.Printf("Failed to promote bootstrap admins: %v", err)
+		return
+	}
+	if promoted > 0 {
+		log.Printf("Promoted %d bootstrap admin account(s)", promoted)
+	}
+}
+
+// isBootstrapAdmin reports whether the user has a verified email listed in
+// ADMIN_BOOTSTRAP_EMAILS.
+func isBootstrapAdmin(user *infrastructure.User) bool {
+	if user.Email == nil || user.EmailVerifiedAt == nil {
+		return false
+	}
+	for _, admin := range bootstrapAdminEmails() {
+		if strings.EqualFold(*user.Email, admin) {
+			return true
+		}
+	}
+	return false
+}
+
+// roleForNewUser returns the role a newly created account should start with.
+// Bootstrap accounts whose email is not verified yet start as players and are
+// promoted once they verify it.
+func roleForNewUser(user *infrastructure.User) infrastructure.Role {
+	if isBootstrapAdmin(user) {
+		return infrastructure.RoleAdmin
+	}
+	return infrastructure.RolePlayer
+}
+
+// promoteBootstrapAdmin makes the user an admin if they just verified a
+// bootstrap email.
+func (s *AuthService) promoteBootstrapAdmin(user *infrastructure.User) {
+	if user.Role == infrastructure.RoleAdmin || !isBootstrapAdmin(user) {
+		return
+	}
+	if err := s.userRepo.UpdateUserRole(user.ID, infrastructure.RoleAdmin); err != nil {
+		log.Printf("Failed to promote bootstrap admin %d: %v", user.ID, err)
+		return
+	}
+	user.Role = infrastructure.RoleAdmin
+	log.Printf("Promoted bootstrap admin account %d", user.ID)
+}
+
+// UpdateUserRoleHandler godoc
+// @Summary      Change a user's role
+// @Description  Grants a role (player, content-editor, admin) to a user and signs them out everywhere, so no token keeps the old role. Admin only.
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "User ID"
+// @Param        request body dto.UpdateRoleRequest true "New role"
+// @Success      200 {object} infrastructure.User
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /admin/users/{id}/role [put]
+func (s *AuthService) UpdateUserRoleHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := infrastructure.Role(req.Role)
+	if !role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return
+	}
+
+	// Admins cannot change their own role, so the last admin cannot lock everyone out.
+	if callerID, _ := CurrentUserID(c); callerID == uint(id) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	user, err := s.userRepo.GetUserByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Role == role {
+		c.JSON(http.StatusOK, gin.H{"user": user})
+		return
+	}
+
+	if err := s.userRepo.UpdateUserRole(user.ID, role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	user.Role = role
+	// Access tokens carry the role, so the user signs in again to get one
+	// with the new role.
+	if err := s.revokeOtherSessions(user.ID, 0); err != nil {
+		log.Printf("Failed to revoke sessions of user %d after role change: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Role changed but sessions could not be revoked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
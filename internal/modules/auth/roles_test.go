@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func TestRoleForNewUserRequiresVerifiedEmail(t *testing.T) {
+	t.Setenv("ADMIN_BOOTSTRAP_EMAILS", "Root@Example.com, ops@example.com")
+	verifiedAt := time.Now()
+
+	cases := []struct {
+		name  string
+		user  infrastructure.User
+		admin bool
+	}{
+		{"verified bootstrap email", infrastructure.User{Email: strPtr("root@example.com"), EmailVerifiedAt: &verifiedAt}, true},
+		{"unverified bootstrap email", infrastructure.User{Email: strPtr("root@example.com")}, false},
+		{"verified other email", infrastructure.User{Email: strPtr("fern@example.com"), EmailVerifiedAt: &verifiedAt}, false},
+		{"no email", infrastructure.User{}, false},
+	}
+	for _, tc := range cases {
+		want := infrastructure.RolePlayer
+		if tc.admin {
+			want = infrastructure.RoleAdmin
+		}
+		if got := roleForNewUser(&tc.user); got != want {
+			t.Errorf("%s: role %q, want %q", tc.name, got, want)
+		}
+	}
+}
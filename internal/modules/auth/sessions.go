@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// Clients describe themselves with these headers on login and refresh.
+const (
+	headerPlatform   = "X-Client-Platform"
+	headerAppVersion = "X-App-Version"
+	headerDeviceName = "X-Device-Name"
+)
+
+// sessionTouchInterval limits how often an authenticated request writes the
+// session's last-seen time.
+const sessionTouchInterval = time.Minute
+
+// truncate cuts s to at most n bytes so client supplied headers fit their
+// columns. The cut backs off to a rune boundary, as Postgres rejects a
+// string ending in half a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// clientDevice describes the device making the request.
+func clientDevice(c *gin.Context) infrastructure.Session {
+	return infrastructure.Session{
+		Platform:   truncate(c.GetHeader(headerPlatform), 32),
+		AppVersion: truncate(c.GetHeader(headerAppVersion), 32),
+		DeviceName: truncate(c.GetHeader(headerDeviceName), 255),
+		UserAgent:  truncate(c.Request.UserAgent(), 255),
+		LastIP:     truncate(c.ClientIP(), 64),
+	}
+}
+
+// sessionActive reports whether the session belongs to userID and has not
+// been revoked, and records the request as activity on it.
+func (s *AuthService) sessionActive(c *gin.Context, userID, sessionID uint) bool {
+	session, err := s.userRepo.GetSessionByID(sessionID)
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		return false
+	}
+
+	ip := c.ClientIP()
+	if session.LastIP != ip || time.Since(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.userRepo.TouchSession(session.ID, truncate(ip, 64)); err != nil {
+			log.Printf("Failed to update session %d: %v", session.ID, err)
+		}
+	}
+	return true
+}
+
+// sessionForFamily returns the session a refresh token belongs to, updated
+// with what the device reported on this refresh. Token families started
+// before sessions existed get one now.
+func (s *AuthService) sessionForFamily(token *infrastructure.RefreshToken, device infrastructure.Session) (*infrastructure.Session, error) {
+	session, err := s.userRepo.GetSessionByFamilyID(token.FamilyID)
+	if err != nil {
+		device.UserID = token.UserID
+		device.FamilyID = token.FamilyID
+		if err := s.userRepo.CreateSession(&device); err != nil {
+			return nil, err
+		}
+		return &device, nil
+	}
+
+	if device.Platform != "" {
+		session.Platform = device.Platform
+	}
+	if device.AppVersion != "" {
+		session.AppVersion = device.AppVersion
+	}
+	if device.DeviceName != "" {
+		session.DeviceName = device.DeviceName
+	}
+	if device.UserAgent != "" {
+		session.UserAgent = device.UserAgent
+	}
+	session.LastIP = device.LastIP
+	session.LastSeenAt = time.Now().UTC()
+	if err := s.userRepo.UpdateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// deactivateSessionPush stops push notifications to a revoked session's
+// device. Failures are logged; the session is already revoked.
+func (s *AuthService) deactivateSessionPush(session *infrastructure.Session) {
+	if err := s.notificationRepo.DeactivateSessionFCMToken(session.ID); err != nil {
+		log.Printf("Failed to deactivate FCM token for session %d: %v", session.ID, err)
+	}
+}
+
+// revokeOtherSessions signs the user out of every session except keepID and
+// stops pushes to those devices. With keepID 0 every session is revoked.
+func (s *AuthService) revokeOtherSessions(userID, keepID uint) error {
+	if keepID == 0 {
+		if err := s.userRepo.RevokeUserRefreshTokens(userID); err != nil {
+			return err
+		}
+		if err := s.notificationRepo.DeactivateFCMToken(userID); err != nil {
+			log.Printf("Failed to deactivate FCM tokens for user %d: %v", userID, err)
+		}
+		return nil
+	}
+
+	sessions, err := s.userRepo.GetActiveSessions(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.RevokeOtherRefreshTokens(userID, keepID); err != nil {
+		return err
+	}
+	for i := range sessions {
+		if sessions[i].ID != keepID {
+			s.deactivateSessionPush(&sessions[i])
+		}
+	}
+	return nil
+}
+
+func sessionResponse(session infrastructure.Session, currentID uint) dto.SessionResponse {
+	return dto.SessionResponse{
+		ID:         session.ID,
+		Platform:   session.Platform,
+		AppVersion: session.AppVersion,
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		LastIP:     session.LastIP,
+		LastSeenAt: session.LastSeenAt,
+		CreatedAt:  session.CreatedAt,
+		Current:    session.ID == currentID,
+	}
+}
+
+// ListSessionsHandler godoc
+// @Summary      List active sessions
+// @Description  Lists the devices the caller is signed in on, most recently used first. The session the request was made from is marked current.
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {array} dto.SessionResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/sessions [get]
+func (s *AuthService) ListSessionsHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessions, err := s.userRepo.GetActiveSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load sessions"})
+		return
+	}
+
+	currentID, _ := CurrentSessionID(c)
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionResponse(session, currentID))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSessionHandler godoc
+// @Summary      Revoke a session
+// @Description  Signs one of the caller's devices out: its refresh tokens stop working, access tokens minted for it are rejected and its push token is deactivated
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id path int true "Session ID"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      404 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/sessions/{id} [delete]
+func (s *AuthService) RevokeSessionHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	// Other users' sessions are reported as missing rather than forbidden so
+	// IDs cannot be probed.
+	session, err := s.userRepo.GetSessionByID(uint(id))
+	if err != nil || session.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := s.userRepo.RevokeSession(session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	s.deactivateSessionPush(session)
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Session revoked"})
+}
+
+// RevokeOtherSessionsHandler godoc
+// @Summary      Revoke all other sessions
+// @Description  Signs the caller out everywhere except the device making the request
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/sessions [delete]
+func (s *AuthService) RevokeOtherSessionsHandler(c *gin.Context) {
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	currentID, ok := CurrentSessionID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is not bound to a session; use logout-all instead"})
+		return
+	}
+
+	if err := s.revokeOtherSessions(userID, currentID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Signed out from all other devices"})
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientDevice(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/auth/login", nil)
+	c.Request.RemoteAddr = "203.0.113.7:5555"
+	c.Request.Header.Set("User-Agent", "PlantGo/2.1 okhttp")
+	c.Request.Header.Set(headerPlatform, "android")
+	c.Request.Header.Set(headerAppVersion, strings.Repeat("9", 40))
+	c.Request.Header.Set(headerDeviceName, "Pixel 8")
+
+	device := clientDevice(c)
+
+	if device.Platform != "android" || device.DeviceName != "Pixel 8" || device.UserAgent != "PlantGo/2.1 okhttp" {
+		t.Errorf("unexpected device: %+v", device)
+	}
+	if device.LastIP != "203.0.113.7" {
+		t.Errorf("LastIP = %q", device.LastIP)
+	}
+	if len(device.AppVersion) != 32 {
+		t.Errorf("expected app version to be truncated to 32 bytes, got %d", len(device.AppVersion))
+	}
+}
+
+func TestClientDeviceTruncatesOnRuneBoundary(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/auth/login", nil)
+	// 128 two-byte runes: the 255 byte cut falls inside the last one.
+	c.Request.Header.Set(headerDeviceName, strings.Repeat("é", 128))
+
+	device := clientDevice(c)
+
+	if !utf8.ValidString(device.DeviceName) {
+		t.Fatalf("device name is not valid UTF-8: %q", device.DeviceName)
+	}
+	if device.DeviceName != strings.Repeat("é", 127) {
+		t.Errorf("expected 127 whole runes, got %d bytes", len(device.DeviceName))
+	}
+}
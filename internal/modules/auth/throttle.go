@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// loginPolicy decides when repeated login failures lock an account or IP.
+// Below threshold nothing happens; from then on each failure doubles the
+// lockout, starting at baseLockout and capped at maxLockout. Failures older
+// than window are forgotten.
+type loginPolicy struct {
+	threshold   int
+	baseLockout time.Duration
+	maxLockout  time.Duration
+	window      time.Duration
+}
+
+func (p loginPolicy) lockoutFor(failures int) time.Duration {
+	if failures < p.threshold {
+		return 0
+	}
+	exp := failures - p.threshold
+	if exp > 30 {
+		exp = 30
+	}
+	d := time.Duration(float64(p.baseLockout) * math.Pow(2, float64(exp)))
+	if d > p.maxLockout || d <= 0 {
+		return p.maxLockout
+	}
+	return d
+}
+
+// accountLoginPolicy applies per email or device ID; LOGIN_MAX_ATTEMPTS
+// failures are allowed before the first lockout.
+func accountLoginPolicy() loginPolicy {
+	return loginPolicy{
+		threshold:   env.Int("LOGIN_MAX_ATTEMPTS", 5),
+		baseLockout: 30 * time.Second,
+		maxLockout:  env.Duration("LOGIN_MAX_LOCKOUT", time.Hour),
+		window:      24 * time.Hour,
+	}
+}
+
+// ipLoginPolicy applies per client IP and is looser, since many players can
+// share an address; LOGIN_IP_MAX_ATTEMPTS failures are allowed.
+func ipLoginPolicy() loginPolicy {
+	return loginPolicy{
+		threshold:   env.Int("LOGIN_IP_MAX_ATTEMPTS", 20),
+		baseLockout: time.Minute,
+		maxLockout:  env.Duration("LOGIN_MAX_LOCKOUT", time.Hour),
+		window:      time.Hour,
+	}
+}
+
+// normalizeIdentifier makes the throttle key for an email case-insensitive.
+func normalizeIdentifier(identifier string) string {
+	return strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// lockedUntil returns the latest active lockout on the account identifier or
+// the caller's IP. Unknown identifiers are tracked like known ones so a
+// lockout does not reveal whether an email is registered.
+func (s *AuthService) lockedUntil(c *gin.Context, identifier string) (time.Time, bool) {
+	now := time.Now().UTC()
+	var until time.Time
+	checks := []struct {
+		scope infrastructure.ThrottleScope
+		key   string
+	}{
+		{infrastructure.ThrottleScopeAccount, normalizeIdentifier(identifier)},
+		{infrastructure.ThrottleScopeIP, c.ClientIP()},
+	}
+	for _, check := range checks {
+		if check.key == "" {
+			continue
+		}
+		throttle, err := s.userRepo.GetLoginThrottle(check.scope, check.key)
+		if err != nil || throttle.LockedUntil == nil {
+			continue
+		}
+		if throttle.LockedUntil.After(now) && throttle.LockedUntil.After(until) {
+			until = *throttle.LockedUntil
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// checkLoginAllowed responds with 429 and returns false while the identifier
+// or the caller's IP is locked out.
+func (s *AuthService) checkLoginAllowed(c *gin.Context, method, identifier string) bool {
+	until, locked := s.lockedUntil(c, identifier)
+	if !locked {
+		return true
+	}
+	s.auditLoginFailure(c, method, identifier, nil, "locked")
+	retryAfter := int(math.Ceil(time.Until(until).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts. Try again later."})
+	return false
+}
+
+// recordLoginFailure audits a failed login and counts it against both the
+// identifier and the caller's IP.
+func (s *AuthService) recordLoginFailure(c *gin.Context, method, identifier string, userID *uint, reason string) {
+	s.auditLoginFailure(c, method, identifier, userID, reason)
+
+	account := accountLoginPolicy()
+	if _, err := s.userRepo.RecordLoginFailure(infrastructure.ThrottleScopeAccount, normalizeIdentifier(identifier), account.window, account.lockoutFor); err != nil {
+		log.Printf("Failed to record login failure for account: %v", err)
+	}
+	ip := ipLoginPolicy()
+	if _, err := s.userRepo.RecordLoginFailure(infrastructure.ThrottleScopeIP, c.ClientIP(), ip.window, ip.lockoutFor); err != nil {
+		log.Printf("Failed to record login failure for IP: %v", err)
+	}
+}
+
+// recordLoginSuccess clears the failures counted against the identifier. The
+// IP counter is left alone so one valid account cannot reset an attacker's
+// budget.
+func (s *AuthService) recordLoginSuccess(identifier string) {
+	if err := s.userRepo.ClearLoginFailures(infrastructure.ThrottleScopeAccount, normalizeIdentifier(identifier)); err != nil {
+		log.Printf("Failed to clear login failures: %v", err)
+	}
+}
+
+func (s *AuthService) auditLoginFailure(c *gin.Context, method, identifier string, userID *uint, reason string) {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	attempt := &infrastructure.LoginAttempt{
+		UserID:     userID,
+		Method:     method,
+		Identifier: normalizeIdentifier(identifier),
+		IP:         c.ClientIP(),
+		UserAgent:  userAgent,
+		Reason:     reason,
+	}
+	if err := s.userRepo.CreateLoginAttempt(attempt); err != nil {
+		log.Printf("Failed to audit login attempt: %v", err)
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash is compared against when an email is unknown or has no
+// password, so those paths take as long as a real password check. It uses
+// the same cost as new password hashes.
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("plantgo-dummy-password"), passwordHashCost())
+		if err != nil {
+			log.Printf("Failed to generate dummy password hash: %v", err)
+			return
+		}
+		dummyHash = string(hash)
+	})
+	return dummyHash
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginPolicyLockout(t *testing.T) {
+	p := loginPolicy{threshold: 3, baseLockout: 30 * time.Second, maxLockout: 10 * time.Minute}
+
+	cases := map[int]time.Duration{
+		1:   0,
+		2:   0,
+		3:   30 * time.Second,
+		4:   time.Minute,
+		5:   2 * time.Minute,
+		8:   10 * time.Minute,
+		100: 10 * time.Minute,
+	}
+	for failures, want := range cases {
+		if got := p.lockoutFor(failures); got != want {
+			t.Errorf("lockoutFor(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	if got := normalizeIdentifier("  Player@Example.COM "); got != "player@example.com" {
+		t.Errorf("normalizeIdentifier() = %q", got)
+	}
+}
+
+func TestDummyPasswordHashNeverMatches(t *testing.T) {
+	if dummyPasswordHash() == "" {
+		t.Fatal("expected a dummy hash")
+	}
+	if verifyPassword("password", dummyPasswordHash()) {
+		t.Error("dummy hash should not match an ordinary password")
+	}
+}
+
+func TestDummyPasswordHashUsesConfiguredCost(t *testing.T) {
+	t.Setenv("PASSWORD_BCRYPT_COST", "5")
+	dummyHashOnce, dummyHash = sync.Once{}, ""
+	t.Cleanup(func() { dummyHashOnce, dummyHash = sync.Once{}, "" })
+
+	cost, err := bcrypt.Cost([]byte(dummyPasswordHash()))
+	if err != nil {
+		t.Fatalf("bcrypt.Cost: %v", err)
+	}
+	if cost != 5 {
+		t.Errorf("dummy hash cost = %d, want the configured 5", cost)
+	}
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// accessTokenTTL is how long an access token is valid, configurable through
+// JWT_ACCESS_TTL (e.g. "15m").
+func accessTokenTTL() time.Duration {
+	return env.Duration("JWT_ACCESS_TTL", defaultAccessTokenTTL)
+}
+
+// refreshTokenTTL is how long a refresh token is valid, configurable through
+// REFRESH_TOKEN_TTL (e.g. "720h").
+func refreshTokenTTL() time.Duration {
+	return env.Duration("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+// Claims is the payload carried by every PlantGo access token.
+type Claims struct {
+	Email    string              `json:"email"`
+	Username string              `json:"username"`
+	Role     infrastructure.Role `json:"role"`
+	// SessionID identifies the device session the token was minted for.
+	SessionID uint `json:"sid,omitempty"`
+	// TwoFactor is set when the session's login passed a second factor.
+	TwoFactor bool `json:"tfa,omitempty"`
+	// Purpose marks tokens that are not access tokens, such as two-factor
+	// login challenges. Access tokens leave it empty.
+	Purpose string `json:"purpose,omitempty"`
+	// MergeGuestID is set on a two-factor challenge when a guest is to be
+	// merged into the account once the second factor is verified.
+	MergeGuestID uint `json:"merge_guest,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the numeric user ID stored in the subject claim.
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid subject claim: %w", err)
+	}
+	return uint(id), nil
+}
+
+// jwtIssuer is the iss claim stamped on and required of every access token.
+func jwtIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "plantgo"
+}
+
+func (s *AuthService) generateSessionJWT(user infrastructure.User, session *infrastructure.Session) (string, error) {
+	var email string
+	if user.Email != nil {
+		email = *user.Email
+	}
+
+	claims := Claims{
+		Email:    email,
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  jwtIssuer(),
+			Subject: strconv.FormatUint(uint64(user.ID), 10),
+		},
+	}
+	if session != nil {
+		claims.SessionID = session.ID
+		claims.TwoFactor = session.TwoFactorAt != nil
+	}
+
+	return s.signClaims(claims, accessTokenTTL())
+}
+
+// signClaims stamps issue and expiry times on claims and signs them.
+func (s *AuthService) signClaims(claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	return s.keys.sign(claims)
+}
+
+// parseJWT validates the signature, issuer and expiry of an access token and
+// returns its claims.
+func (s *AuthService) parseJWT(tokenString string) (*Claims, error) {
+	claims, err := s.parseSignedClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("token is not an access token")
+	}
+	return claims, nil
+}
+
+// parseSignedClaims checks any token signed by this service.
+func (s *AuthService) parseSignedClaims(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keys.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no expiry")
+	}
+	if !claims.VerifyIssuer(jwtIssuer(), true) {
+		return nil, errors.New("unexpected token issuer")
+	}
+	return claims, nil
+}
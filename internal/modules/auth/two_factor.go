@@ -0,0 +1,501 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// TOTP parameters from RFC 6238, as every authenticator app supports them.
+const (
+	totpDigits      = 6
+	totpPeriod      = 30 // seconds
+	totpSkew        = 1  // steps accepted either side of now, for clock drift
+	totpSecretBytes = 20
+
+	recoveryCodeCount = 10
+	// recoveryCodeBytes gives 80 bits per code, enough that a plain SHA-256
+	// is safe to store.
+	recoveryCodeBytes = 10
+
+	defaultTwoFactorChallengeTTL = 5 * time.Minute
+	purposeTwoFactorChallenge    = "2fa_challenge"
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// twoFactorChallengeTTL is how long a login has to supply its second factor,
+// configurable through TWO_FACTOR_CHALLENGE_TTL.
+func twoFactorChallengeTTL() time.Duration {
+	return env.Duration("TWO_FACTOR_CHALLENGE_TTL", defaultTwoFactorChallengeTTL)
+}
+
+// twoFactorRequired reports whether role is listed in the comma separated
+// TWO_FACTOR_REQUIRED_ROLES, e.g. "admin" or "admin,content-editor".
+func twoFactorRequired(role infrastructure.Role) bool {
+	return containsString(splitEnvList(os.Getenv("TWO_FACTOR_REQUIRED_ROLES")), string(role))
+}
+
+// totpIssuer names the service in authenticator apps, TOTP_ISSUER or
+// "PlantGo".
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "PlantGo"
+}
+
+func newTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// totpProvisioningURI is the otpauth:// URI authenticator apps import from a
+// QR code.
+func totpProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(totpDigits))
+	query.Set("period", strconv.Itoa(totpPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// totpCode computes the code for one time step (RFC 4226 HOTP).
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// verifyTOTP checks code against the secret around now and returns the time
+// step it matched.
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if !looksLikeTOTP(code) {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := current + offset
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func looksLikeTOTP(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCodes returns codes to show the user once, formatted as
+// "xxxx-xxxx-xxxx-xxxx", and the hashes to store.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(totpEncoding.EncodeToString(b))
+		var groups []string
+		for j := 0; j < len(raw); j += 4 {
+			groups = append(groups, raw[j:min(j+4, len(raw))])
+		}
+		codes = append(codes, strings.Join(groups, "-"))
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed with any case, spacing or dashes.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
+
+// issueTwoFactorChallenge signs a short-lived token that proves the user got
+// past the first factor. It is not an access token. A non-zero mergeGuestID
+// names a guest to merge into the user once the second factor is verified.
+func (s *AuthService) issueTwoFactorChallenge(user *infrastructure.User, mergeGuestID uint) (string, error) {
+	return s.signClaims(Claims{
+		Purpose:      purposeTwoFactorChallenge,
+		MergeGuestID: mergeGuestID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:  jwtIssuer(),
+			Subject: strconv.FormatUint(uint64(user.ID), 10),
+		},
+	}, twoFactorChallengeTTL())
+}
+
+// parseTwoFactorChallenge returns the user a challenge token was issued to
+// and the guest to merge into them, if any.
+func (s *AuthService) parseTwoFactorChallenge(token string) (userID, mergeGuestID uint, err error) {
+	claims, err := s.parseSignedClaims(token)
+	if err != nil {
+		return 0, 0, err
+	}
+	if claims.Purpose != purposeTwoFactorChallenge {
+		return 0, 0, errors.New("token is not a two-factor challenge")
+	}
+	userID, err = claims.UserID()
+	return userID, claims.MergeGuestID, err
+}
+
+// respondWithLogin finishes a successful first-factor login: with tokens, or
+// with a challenge if the account has two-factor login enabled.
+func (s *AuthService) respondWithLogin(c *gin.Context, user *infrastructure.User) {
+	if !user.TwoFactorEnabled() {
+		s.respondWithTokens(c, http.StatusOK, user)
+		return
+	}
+	s.respondWithChallenge(c, user, 0, "")
+}
+
+// respondWithChallenge asks for the second factor of a user with two-factor
+// login enabled. mergeGuestID is carried as for issueTwoFactorChallenge, and
+// a newly issued device secret is passed on so it is not lost.
+func (s *AuthService) respondWithChallenge(c *gin.Context, user *infrastructure.User, mergeGuestID uint, deviceSecret string) {
+	challenge, err := s.issueTwoFactorChallenge(user, mergeGuestID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+		ExpiresIn:         int64(twoFactorChallengeTTL().Seconds()),
+		DeviceSecret:      deviceSecret,
+	})
+}
+
+// checkSecondFactor verifies a TOTP code, or with allowRecovery a recovery
+// code, writing an error response and returning false if it is wrong. Each
+// code works once and wrong codes are throttled like failed logins.
+func (s *AuthService) checkSecondFactor(c *gin.Context, user *infrastructure.User, code string, allowRecovery bool) bool {
+	key := infrastructure.TwoFactorThrottleKey(user.ID)
+	if !s.checkLoginAllowed(c, "two_factor", key) {
+		return false
+	}
+
+	if user.TOTPSecret != nil {
+		if step, ok := verifyTOTP(*user.TOTPSecret, code, time.Now()); ok {
+			used, err := s.userRepo.UseTOTPStep(user.ID, step)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+				return false
+			}
+			if used {
+				s.recordLoginSuccess(key)
+				return true
+			}
+		}
+	}
+
+	if allowRecovery && !looksLikeTOTP(strings.TrimSpace(code)) {
+		used, err := s.userRepo.UseRecoveryCode(user.ID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify code"})
+			return false
+		}
+		if used {
+			log.Printf("User %d signed in with a recovery code", user.ID)
+			s.recordLoginSuccess(key)
+			return true
+		}
+	}
+
+	s.recordLoginFailure(c, "two_factor", key, &user.ID, "wrong_code")
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+	return false
+}
+
+// VerifyTwoFactorHandler godoc
+// @Summary      Complete a two-factor login
+// @Description  Exchanges the challenge token from a login and a code from the authenticator app, or an unused recovery code, for access and refresh tokens. If the challenge came from linking a guest to this account, the guest is merged into it first.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body dto.TwoFactorVerifyRequest true "Challenge token and code"
+// @Success      200 {object} dto.AuthResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/2fa/verify [post]
+func (s *AuthService) VerifyTwoFactorHandler(c *gin.Context) {
+	var req dto.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, mergeGuestID, err := s.parseTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge; sign in again"})
+		return
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil || !user.TwoFactorEnabled() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge; sign in again"})
+		return
+	}
+
+	if !s.checkSecondFactor(c, user, req.Code, true) {
+		return
+	}
+	if mergeGuestID != 0 {
+		guest, err := s.userRepo.GetUserByID(mergeGuestID)
+		if err != nil || !guest.IsGuest() {
+			c.JSON(http.StatusConflict, gin.H{"error": "The guest account can no longer be merged; sign in again"})
+			return
+		}
+		merged, ok := s.mergeGuest(c, guest, user)
+		if !ok {
+			return
+		}
+		user = merged
+	}
+
+	device := clientDevice(c)
+	now := time.Now().UTC()
+	device.TwoFactorAt = &now
+	response, err := s.authResponseForDevice(user, device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create JWT"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupTOTPHandler godoc
+// @Summary      Start TOTP enrollment
+// @Description  Creates a new TOTP secret for the caller. Show the provisioning URI as a QR code, then confirm with a code from the app; until then logins are unaffected.
+// @Tags         Auth
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200 {object} dto.TOTPSetupResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/2fa/totp/setup [post]
+func (s *AuthService) SetupTOTPHandler(c *gin.Context) {
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create secret"})
+		return
+	}
+	if err := s.userRepo.SetPendingTOTP(user.ID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store secret"})
+		return
+	}
+
+	account := user.Username
+	if user.Email != nil && *user.Email != "" {
+		account = *user.Email
+	}
+	c.JSON(http.StatusOK, dto.TOTPSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(totpIssuer(), account, secret),
+	})
+}
+
+// ConfirmTOTPHandler godoc
+// @Summary      Confirm TOTP enrollment
+// @Description  Turns on two-factor login once a code from the authenticator app checks out, and returns recovery codes. The codes are only shown here. Sign in again to use routes that require two-factor authentication.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success      200 {object} dto.RecoveryCodesResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/2fa/totp/confirm [post]
+func (s *AuthService) ConfirmTOTPHandler(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPSecret == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Start TOTP setup first"})
+		return
+	}
+	if !s.checkSecondFactor(c, user, req.Code, false) {
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	if err := s.userRepo.EnableTOTP(user.ID, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTPHandler godoc
+// @Summary      Turn off two-factor login
+// @Description  Removes the TOTP secret and recovery codes after checking a current TOTP or recovery code. Not allowed for roles that require two-factor authentication.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success      200 {object} dto.SuccessResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      403 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/2fa/totp/disable [post]
+func (s *AuthService) DisableTOTPHandler(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if twoFactorRequired(user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your role"})
+		return
+	}
+	if !s.checkSecondFactor(c, user, req.Code, true) {
+		return
+	}
+
+	if err := s.userRepo.DisableTOTP(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.SuccessResponse{Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodesHandler godoc
+// @Summary      Replace recovery codes
+// @Description  Issues a new set of recovery codes after checking a current TOTP code. The old codes stop working.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        request body dto.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success      200 {object} dto.RecoveryCodesResponse
+// @Failure      400 {object} dto.ErrorResponse
+// @Failure      401 {object} dto.ErrorResponse
+// @Failure      409 {object} dto.ErrorResponse
+// @Failure      429 {object} dto.ErrorResponse
+// @Failure      500 {object} dto.ErrorResponse
+// @Router       /auth/2fa/recovery-codes [post]
+func (s *AuthService) RegenerateRecoveryCodesHandler(c *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := s.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !s.checkSecondFactor(c, user, req.Code, false) {
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create recovery codes"})
+		return
+	}
+	if err := s.userRepo.ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	// RFC 6238 appendix B, SHA-1, truncated to six digits.
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		if got := totpCode(key, unix/totpPeriod); got != want {
+			t.Errorf("totpCode at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := totpEncoding.DecodeString(secret)
+	now := time.Unix(1700000000, 0)
+	current := now.Unix() / totpPeriod
+
+	if step, ok := verifyTOTP(secret, totpCode(key, current), now); !ok || step != current {
+		t.Errorf("expected the current code to verify at step %d, got %d %v", current, step, ok)
+	}
+	if step, ok := verifyTOTP(secret, totpCode(key, current-1), now); !ok || step != current-1 {
+		t.Error("expected the previous step to be accepted for clock drift")
+	}
+	spaced := totpCode(key, current)
+	if _, ok := verifyTOTP(secret, spaced[:3]+" "+spaced[3:], now); !ok {
+		t.Error("expected a code typed with a space to verify")
+	}
+	for _, code := range []string{totpCode(key, current-2), totpCode(key, current+2), "", "12345", "abcdef"} {
+		if _, ok := verifyTOTP(secret, code, now); ok && code != totpCode(key, current) {
+			t.Errorf("expected %q to be rejected", code)
+		}
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(totpProvisioningURI("PlantGo", "fern@example.com", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/PlantGo:fern@example.com" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "PlantGo" || query.Get("digits") != "6" {
+		t.Errorf("unexpected query %s", uri.RawQuery)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(codes))
+	}
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if len(code) != 19 || strings.Count(code, "-") != 3 {
+			t.Errorf("unexpected code format %q", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		if hashToken(normalizeRecoveryCode(typed)) != hashes[i] {
+			t.Errorf("code %q typed as %q does not match its hash", code, typed)
+		}
+		if looksLikeTOTP(code) {
+			t.Errorf("recovery code %q would be mistaken for a TOTP code", code)
+		}
+	}
+}
+
+func TestTwoFactorChallengeIsNotAnAccessToken(t *testing.T) {
+	s := newTestAuthService()
+	user := &infrastructure.User{ID: 42, Username: "fern"}
+
+	challenge, err := s.issueTwoFactorChallenge(user, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr := performRequest(newMiddlewareRouter(s), "Bearer "+challenge); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected a challenge token to be rejected as an access token, got %d", rr.Code)
+	}
+	if userID, guestID, err := s.parseTwoFactorChallenge(challenge); err != nil || userID != 42 || guestID != 0 {
+		t.Errorf("expected the challenge to name user 42 and no guest, got %d %d %v", userID, guestID, err)
+	}
+
+	access, err := s.generateSessionJWT(*user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.parseTwoFactorChallenge(access); err == nil {
+		t.Error("expected an access token to be rejected as a challenge")
+	}
+}
+
+func TestRequireRoleEnforcesTwoFactor(t *testing.T) {
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "admin")
+	s := newTestAuthService()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/protected", s.AuthMiddleware(), RequireRole(infrastructure.RoleContentEditor, infrastructure.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	verifiedAt := time.Now()
+	cases := []struct {
+		name    string
+		role    infrastructure.Role
+		session *infrastructure.Session
+		want    int
+	}{
+		{"admin without second factor", infrastructure.RoleAdmin, nil, http.StatusForbidden},
+		{"admin with second factor", infrastructure.RoleAdmin, &infrastructure.Session{TwoFactorAt: &verifiedAt}, http.StatusOK},
+		{"editor without second factor", infrastructure.RoleContentEditor, nil, http.StatusOK},
+	}
+	for _, tc := range cases {
+		token, err := s.generateSessionJWT(infrastructure.User{ID: 7, Role: tc.role}, tc.session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/auth/infrastructure"
+)
+
+// defaultUsername is used when nothing usable is left of a name the server
+// derives a username from.
+const defaultUsername = "Player"
+
+// validateUsername checks a username the user chose and returns it trimmed.
+// Uniqueness needs the database; see checkUsernameAvailable.
+func validateUsername(raw string) (string, error) {
+	username := strings.TrimSpace(raw)
+	if !usernamePattern.MatchString(username) {
+		return "", errors.New("username must be 3-32 letters, digits, '.', '_' or '-'")
+	}
+	if containsProfanity(username) {
+		return "", errors.New("username is not allowed")
+	}
+	return username, nil
+}
+
+// deriveUsername turns a name the user didn't pick as a username, such as
+// their Google profile name or a guest's device name, into one that passes
+// validateUsername. Spaces become underscores and other characters are
+// dropped; if too little is left, or it is profane, defaultUsername is used.
+// The result is made unique when the account is created.
+func deriveUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.-", r)):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+	username := b.String()
+	if len(username) > infrastructure.UsernameMaxLength {
+		username = username[:infrastructure.UsernameMaxLength]
+	}
+	if _, err := validateUsername(username); err != nil {
+		return defaultUsername
+	}
+	return username
+}
+
+// checkUsernameAvailable responds with 409 and returns false if another
+// account has username. The unique index still catches a racing request;
+// see usernameSaveFailed.
+func (s *AuthService) checkUsernameAvailable(c *gin.Context, username string, userID uint) bool {
+	taken, err := s.userRepo.UsernameTaken(username, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
+		return false
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+		return false
+	}
+	return true
+}
+
+// usernameSaveFailed responds to an error from saving a user, with 409 if
+// another account took the username in the meantime and with message
+// otherwise.
+func usernameSaveFailed(c *gin.Context, err error, message string) {
+	if errors.Is(err, infrastructure.ErrUsernameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username is already taken"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeriveUsername(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"fern", "fern"},
+		{"  Fern  Gully ", "Fern_Gully"},
+		{"José Ramírez", "Jos_Ramrez"},
+		{"Dr. O'Neil-Smith", "Dr._ONeil-Smith"},
+		{strings.Repeat("a", 40), strings.Repeat("a", 32)},
+		{"Al", defaultUsername},
+		{"李小龙", defaultUsername},
+		{"sh1t player", defaultUsername},
+		{"", defaultUsername},
+	}
+	for _, tc := range cases {
+		got := deriveUsername(tc.name)
+		if got != tc.want {
+			t.Errorf("deriveUsername(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+		if _, err := validateUsername(got); err != nil {
+			t.Errorf("deriveUsername(%q) = %q, which fails validation: %v", tc.name, got, err)
+		}
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	if got, err := validateUsername(" Fern_2 "); err != nil || got != "Fern_2" {
+		t.Errorf("validateUsername = %q, %v", got, err)
+	}
+	for _, name := range []string{"ab", "fern gully", "", "sh1t_player", strings.Repeat("a", 33)} {
+		if _, err := validateUsername(name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
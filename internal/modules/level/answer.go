@@ -0,0 +1,265 @@
+package level
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/unicode/norm"
+
+	"plantgo-backend/internal/dto"
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+// letterFolds spells out letters that do not decompose into a base letter
+// plus accent, so "Æ" and "ae" compare equal.
+var letterFolds = strings.NewReplacer("ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d")
+
+// leadingArticles are dropped from answers, so "the sunflower" is accepted.
+var leadingArticles = []string{"the ", "a ", "an "}
+
+// normalizeAnswer reduces a plant name to lowercase ASCII-ish words: accents
+// are stripped, punctuation becomes a space and runs of spaces collapse.
+func normalizeAnswer(s string) string {
+	s = letterFolds.Replace(strings.ToLower(norm.NFD.String(s)))
+
+	var b strings.Builder
+	space := true
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case !space:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	normalized := strings.TrimSpace(b.String())
+
+	for _, article := range leadingArticles {
+		if trimmed := strings.TrimPrefix(normalized, article); trimmed != normalized && trimmed != "" {
+			return trimmed
+		}
+	}
+	return normalized
+}
+
+// typoTolerance is how many edits a guess may be from an accepted answer of
+// n letters. Short names must be exact so that "rose" does not accept "rosa".
+func typoTolerance(n int) int {
+	switch {
+	case n <= 4:
+		return 0
+	case n <= 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// answerMatches reports whether guess names the same plant as one of the
+// accepted answers, ignoring case, accents, spacing and small typos.
+func answerMatches(guess string, accepted []string) bool {
+	compactGuess := strings.ReplaceAll(normalizeAnswer(guess), " ", "")
+	if compactGuess == "" {
+		return false
+	}
+	for _, answer := range accepted {
+		compact := strings.ReplaceAll(normalizeAnswer(answer), " ", "")
+		if compact == "" {
+			continue
+		}
+		if editDistance(compactGuess, compact) <= typoTolerance(len([]rune(compact))) {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance is the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// answerRevealPolicy decides when a player who keeps guessing wrong is shown
+// the answer. A zero field disables that condition; with both zero the answer
+// is only ever shown after a correct guess.
+type answerRevealPolicy struct {
+	afterAttempts int
+	afterDuration time.Duration
+}
+
+// answerRevealPolicyFromEnv reads ANSWER_REVEAL_AFTER_ATTEMPTS, the number of
+// wrong guesses, and ANSWER_REVEAL_AFTER, the time since the first guess.
+func answerRevealPolicyFromEnv() answerRevealPolicy {
+	return answerRevealPolicy{
+		afterAttempts: env.Int("ANSWER_REVEAL_AFTER_ATTEMPTS", 0),
+		afterDuration: env.Duration("ANSWER_REVEAL_AFTER", 0),
+	}
+}
+
+// reveals reports whether the answer may be shown given the player's wrong
+// guesses so far.
+func (p answerRevealPolicy) reveals(stats infrastructure.AttemptStats, now time.Time) bool {
+	if p.afterAttempts > 0 && stats.Failed >= int64(p.afterAttempts) {
+		return true
+	}
+	if p.afterDuration > 0 && stats.FirstAt != nil && now.Sub(*stats.FirstAt) >= p.afterDuration {
+		return true
+	}
+	return false
+}
+
+// SubmitAnswer godoc
+// @Summary      Submit a riddle answer
+// @Description  Checks the caller's guess against the level's plant name, scientific name and aliases, ignoring case, accents and small typos. Every guess is recorded. A correct guess completes the level and pays its reward. The answer is shown after a correct guess, or after the wrong guesses or time configured by ANSWER_REVEAL_AFTER_ATTEMPTS and ANSWER_REVEAL_AFTER; a level solved after its answer was shown pays no reward.
+// @Tags         Game
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key header string false "Client key; a retry with the same key returns the original result"
+// @Param        request body dto.SubmitAnswerRequest true "Level and guess"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      401 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      409 {object} Response
+// @Failure      422 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/me/answer [post]
+// @Router       /levels/answer [post]
+func (h *PlantHandler) SubmitAnswer(c *gin.Context) {
+	var req dto.SubmitAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	key, ok := h.idempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	level, err := h.repository.GetLevelByID(req.LevelID)
+	if err != nil {
+		h.sendError(c, http.StatusNotFound, "Level not found", err)
+		return
+	}
+	completion, ok := h.checkPlayable(c, userID, level, key)
+	if !ok {
+		return
+	}
+	if completion != nil {
+		h.sendAnswerResponse(c, userID, correctAnswerResponse(level, completion))
+		return
+	}
+
+	stats, err := h.repository.GetAttemptStats(userID, level.ID)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to check answer", err)
+		return
+	}
+	policy := answerRevealPolicyFromEnv()
+	now := time.Now().UTC()
+	attempt := &infrastructure.LevelAttempt{
+		UserID:    userID,
+		LevelID:   level.ID,
+		Method:    infrastructure.AttemptAnswer,
+		Answer:    strings.TrimSpace(req.Answer),
+		IsCorrect: answerMatches(req.Answer, level.AcceptedAnswers()),
+		CreatedAt: now,
+	}
+
+	var response dto.SubmitAnswerResponse
+	if attempt.IsCorrect {
+		completion, err := h.repository.CompleteLevelWithAttempt(attempt, level, answerReward(level, stats), key)
+		if err != nil {
+			h.sendCompletionError(c, err)
+			return
+		}
+		if !completion.Replayed {
+			h.notifyLevelComplete(userID, level, completion.Reward)
+		}
+		response = correctAnswerResponse(level, completion)
+	} else {
+		stats.Failed++
+		if stats.FirstAt == nil {
+			stats.FirstAt = &now
+		}
+		// The attempt remembers that it showed the answer, so a later correct
+		// guess pays nothing.
+		attempt.RevealedAnswer = policy.reveals(stats, now)
+		if err := h.repository.RecordAttempt(attempt); err != nil {
+			h.sendError(c, http.StatusInternalServerError, "Failed to record answer", err)
+			return
+		}
+
+		response = dto.SubmitAnswerResponse{Message: "Not quite, try again"}
+		if attempt.RevealedAnswer {
+			response.Message = "Not quite. Here is the answer"
+			response.CorrectAnswer = level.PlantName
+		}
+	}
+
+	h.sendAnswerResponse(c, userID, response)
+}
+
+// answerReward is what a correct guess pays: the level's reward, or nothing
+// once a wrong guess has been shown the answer. Reaching the reveal policy's
+// thresholds alone does not cost the reward.
+func answerReward(level *infrastructure.Level, stats infrastructure.AttemptStats) int {
+	if stats.RevealedAt != nil {
+		return 0
+	}
+	return level.Reward
+}
+
+func correctAnswerResponse(level *infrastructure.Level, completion *infrastructure.LevelCompletion) dto.SubmitAnswerResponse {
+	return dto.SubmitAnswerResponse{
+		IsCorrect:      true,
+		Message:        "Correct! Level completed",
+		RewardGained:   completion.Reward,
+		LevelCompleted: true,
+		CorrectAnswer:  level.PlantName,
+	}
+}
+
+// sendAnswerResponse fills in the caller's balance and writes the response.
+func (h *PlantHandler) sendAnswerResponse(c *gin.Context, userID uint, response dto.SubmitAnswerResponse) {
+	userReward, err := h.repository.GetOrCreateUserReward(userID)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve user reward", err)
+		return
+	}
+	response.TotalRewards = userReward.TotalRewards
+
+	h.sendSuccess(c, response.Message, response)
+}
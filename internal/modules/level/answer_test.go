@@ -0,0 +1,94 @@
+package level
+
+import (
+	"testing"
+	"time"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	cases := map[string]string{
+		"  Sunflower ":        "sunflower",
+		"CRÈME-brûlée":        "creme brulee",
+		"Helianthus  annuus!": "helianthus annuus",
+		"The Venus flytrap":   "venus flytrap",
+		"Ærø rose":            "aero rose",
+		"a":                   "a",
+		"St. John's wort":     "st john s wort",
+	}
+	for input, want := range cases {
+		if got := normalizeAnswer(input); got != want {
+			t.Errorf("normalizeAnswer(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestAnswerMatches(t *testing.T) {
+	level := &infrastructure.Level{
+		PlantName:      "Sunflower",
+		ScientificName: "Helianthus annuus",
+		Aliases:        "common sunflower, girasol",
+	}
+	accepted := level.AcceptedAnswers()
+
+	for _, guess := range []string{
+		"sunflower", "SUN FLOWER", "sunflowr", "helianthus anuus", "Girasól", "the common sunflower",
+	} {
+		if !answerMatches(guess, accepted) {
+			t.Errorf("expected %q to be accepted", guess)
+		}
+	}
+	for _, guess := range []string{"", "!!!", "daisy", "sunfl", "helianthus"} {
+		if answerMatches(guess, accepted) {
+			t.Errorf("expected %q to be rejected", guess)
+		}
+	}
+
+	// Short names allow no typos.
+	if answerMatches("rosa", []string{"Rose"}) {
+		t.Error("expected a typo in a short name to be rejected")
+	}
+}
+
+func TestAnswerRevealPolicy(t *testing.T) {
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+
+	cases := []struct {
+		name   string
+		policy answerRevealPolicy
+		stats  infrastructure.AttemptStats
+		want   bool
+	}{
+		{"disabled", answerRevealPolicy{}, infrastructure.AttemptStats{Failed: 100, FirstAt: &hourAgo}, false},
+		{"too few attempts", answerRevealPolicy{afterAttempts: 3}, infrastructure.AttemptStats{Failed: 2}, false},
+		{"enough attempts", answerRevealPolicy{afterAttempts: 3}, infrastructure.AttemptStats{Failed: 3}, true},
+		{"too soon", answerRevealPolicy{afterDuration: 2 * time.Hour}, infrastructure.AttemptStats{Failed: 1, FirstAt: &hourAgo}, false},
+		{"long enough", answerRevealPolicy{afterDuration: time.Hour}, infrastructure.AttemptStats{Failed: 1, FirstAt: &hourAgo}, true},
+		{"no attempts yet", answerRevealPolicy{afterDuration: time.Minute}, infrastructure.AttemptStats{}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.reveals(tc.stats, now); got != tc.want {
+			t.Errorf("%s: reveals = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAnswerReward(t *testing.T) {
+	level := &infrastructure.Level{Reward: 40}
+	longAgo := time.Now().Add(-24 * time.Hour)
+
+	if got := answerReward(level, infrastructure.AttemptStats{Failed: 50, FirstAt: &longAgo}); got != 40 {
+		t.Errorf("many wrong guesses without a reveal: reward %d, want 40", got)
+	}
+	if got := answerReward(level, infrastructure.AttemptStats{Failed: 3, FirstAt: &longAgo, RevealedAt: &longAgo}); got != 0 {
+		t.Errorf("after the answer was shown: reward %d, want 0", got)
+	}
+}
+
+func TestJoinAliases(t *testing.T) {
+	if got := joinAliases([]string{" girasol ", "", "sun, flower"}); got != "girasol, sun flower" {
+		t.Errorf("joinAliases = %q", got)
+	}
+}
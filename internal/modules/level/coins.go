@@ -0,0 +1,263 @@
+package level
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/auth"
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+const (
+	defaultCoinPageSize = 20
+	maxCoinPageSize     = 100
+)
+
+// CoinAdjustmentRequest credits (positive) or debits (negative) a user's
+// coins. The note is shown in the user's history and the audit trail.
+type CoinAdjustmentRequest struct {
+	Amount int    `json:"amount" binding:"required"`
+	Note   string `json:"note" binding:"required,max=255"`
+}
+
+// CoinReversalRequest explains why a transaction is reversed.
+type CoinReversalRequest struct {
+	Note string `json:"note" binding:"required,max=255"`
+}
+
+// pageParams reads limit and offset, clamping them to sane values.
+func pageParams(c *gin.Context) (limit, offset int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultCoinPageSize)))
+	if err != nil || limit <= 0 {
+		limit = defaultCoinPageSize
+	}
+	if limit > maxCoinPageSize {
+		limit = maxCoinPageSize
+	}
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// sendCoinError writes the response for a failed coin transaction.
+func (h *PlantHandler) sendCoinError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrInsufficientCoins):
+		h.sendError(c, http.StatusConflict, "Not enough coins", nil)
+	case errors.Is(err, infrastructure.ErrDuplicateCoinTransaction):
+		h.sendError(c, http.StatusConflict, "Transaction already recorded", nil)
+	case errors.Is(err, infrastructure.ErrAlreadyReversed):
+		h.sendError(c, http.StatusConflict, "Transaction cannot be reversed again", nil)
+	case errors.Is(err, infrastructure.ErrCoinTransactionNotFound):
+		h.sendError(c, http.StatusNotFound, "Transaction not found", nil)
+	default:
+		h.sendError(c, http.StatusInternalServerError, "Failed to record coin transaction", err)
+	}
+}
+
+// GetCoinTransactions godoc
+// @Summary      Get coin history
+// @Description  Lists the caller's coin transactions, newest first, with the balance after each.
+// @Tags         Game
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        limit query int false "Transactions per page (max 100)" default(20)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200 {object} Response
+// @Failure      401 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/me/coins/transactions [get]
+func (h *PlantHandler) GetCoinTransactions(c *gin.Context) {
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	limit, offset := pageParams(c)
+
+	transactions, total, err := h.repository.GetCoinTransactions(userID, limit, offset)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve coin history", err)
+		return
+	}
+	reward, err := h.repository.GetOrCreateUserReward(userID)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve user reward", err)
+		return
+	}
+
+	h.sendSuccess(c, "Coin history retrieved successfully", map[string]interface{}{
+		"balance":      reward.TotalRewards,
+		"transactions": transactions,
+		"total":        total,
+		"limit":        limit,
+		"offset":       offset,
+		"has_more":     int64(offset+len(transactions)) < total,
+	})
+}
+
+// AdjustUserCoins godoc
+// @Summary      Adjust a user's coins
+// @Description  Records a manual credit or debit in the user's coin ledger. Debits cannot take the balance below zero. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "User ID"
+// @Param        Idempotency-Key header string false "Client key; a retry with the same key is rejected instead of applied twice"
+// @Param        request body CoinAdjustmentRequest true "Amount and reason"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      409 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/users/{id}/coins/adjust [post]
+func (h *PlantHandler) AdjustUserCoins(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	var req CoinAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	key, ok := h.idempotencyKey(c)
+	if !ok {
+		return
+	}
+	adminID, _ := auth.CurrentUserID(c)
+
+	entry, err := h.repository.AdjustCoins(uint(userID), req.Amount, strings.TrimSpace(req.Note), adminID, key)
+	if err != nil {
+		h.sendCoinError(c, err)
+		return
+	}
+	h.sendSuccess(c, "Coins adjusted successfully", entry)
+}
+
+// ReverseCoinTransaction godoc
+// @Summary      Reverse a coin transaction
+// @Description  Records an entry that cancels a transaction, such as a mistaken award. Each transaction can be reversed once. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Coin transaction ID"
+// @Param        request body CoinReversalRequest true "Reason"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      409 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/coin-transactions/{id}/reverse [post]
+func (h *PlantHandler) ReverseCoinTransaction(c *gin.Context) {
+	transactionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid transaction ID", err)
+		return
+	}
+	var req CoinReversalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	adminID, _ := auth.CurrentUserID(c)
+
+	reversal, err := h.repository.ReverseCoinTransaction(uint(transactionID), strings.TrimSpace(req.Note), adminID)
+	if err != nil {
+		h.sendCoinError(c, err)
+		return
+	}
+	h.sendSuccess(c, "Transaction reversed successfully", reversal)
+}
+
+// GetUserCoinTransactions godoc
+// @Summary      Get a user's coin history
+// @Description  Lists a user's coin transactions, newest first, for support. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id path int true "User ID"
+// @Param        limit query int false "Transactions per page (max 100)" default(20)
+// @Param        offset query int false "Offset for pagination" default(0)
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/users/{id}/coins/transactions [get]
+func (h *PlantHandler) GetUserCoinTransactions(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	limit, offset := pageParams(c)
+
+	transactions, total, err := h.repository.GetCoinTransactions(uint(userID), limit, offset)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve coin history", err)
+		return
+	}
+	h.sendSuccess(c, "Coin history retrieved successfully", map[string]interface{}{
+		"transactions": transactions,
+		"total":        total,
+		"limit":        limit,
+		"offset":       offset,
+		"has_more":     int64(offset+len(transactions)) < total,
+	})
+}
+
+// GetBalanceDrift godoc
+// @Summary      Find coin balance drift
+// @Description  Lists users whose cached coin balance differs from the sum of their ledger. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        limit query int false "Maximum users to return (max 100)" default(20)
+// @Success      200 {object} Response
+// @Failure      403 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/coins/drift [get]
+func (h *PlantHandler) GetBalanceDrift(c *gin.Context) {
+	limit, _ := pageParams(c)
+	drift, err := h.repository.FindBalanceDrift(limit)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to check balances", err)
+		return
+	}
+	h.sendSuccess(c, "Balance drift retrieved successfully", drift)
+}
+
+// ReconcileUserCoins godoc
+// @Summary      Reconcile a user's coin balance
+// @Description  Resets the user's cached coin balance to the sum of their ledger and returns the values before the fix. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id path int true "User ID"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/users/{id}/coins/reconcile [post]
+func (h *PlantHandler) ReconcileUserCoins(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	drift, err := h.repository.ReconcileBalance(uint(userID))
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to reconcile balance", err)
+		return
+	}
+	h.sendSuccess(c, "Balance reconciled successfully", drift)
+}
@@ -0,0 +1,46 @@
+package level
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestPageParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		query              string
+		wantLimit, wantOff int
+	}{
+		{"", defaultCoinPageSize, 0},
+		{"?limit=5&offset=10", 5, 10},
+		{"?limit=500", maxCoinPageSize, 0},
+		{"?limit=0&offset=-3", defaultCoinPageSize, 0},
+		{"?limit=abc&offset=xyz", defaultCoinPageSize, 0},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "/game/me/coins/transactions"+tc.query, nil)
+
+		limit, offset := pageParams(c)
+		if limit != tc.wantLimit || offset != tc.wantOff {
+			t.Errorf("pageParams(%q) = %d, %d; want %d, %d", tc.query, limit, offset, tc.wantLimit, tc.wantOff)
+		}
+	}
+}
+
+func TestSendCoinError(t *testing.T) {
+	testErrorStatuses(t, (*PlantHandler).sendCoinError, []errorStatus{
+		{infrastructure.ErrInsufficientCoins, http.StatusConflict},
+		{infrastructure.ErrDuplicateCoinTransaction, http.StatusConflict},
+		{infrastructure.ErrAlreadyReversed, http.StatusConflict},
+		{fmt.Errorf("reverse: %w", infrastructure.ErrCoinTransactionNotFound), http.StatusNotFound},
+		{fmt.Errorf("connection reset"), http.StatusInternalServerError},
+	})
+}
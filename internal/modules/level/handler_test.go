@@ -0,0 +1,101 @@
+package level
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestPlayerLevelHidesAnswer(t *testing.T) {
+	level := &infrastructure.Level{
+		ID:             3,
+		LevelNumber:    3,
+		Riddle:         "Spiky and thirsty for nothing",
+		PlantName:      "Cactus",
+		ScientificName: "Cactaceae",
+		Aliases:        "Prickly pear",
+		Reward:         50,
+	}
+	body, err := json.Marshal(newPlayerLevel(level))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, answer := range []string{"plant_name", "scientific_name", "aliases"} {
+		if _, ok := fields[answer]; ok {
+			t.Errorf("player level exposes %s: %s", answer, body)
+		}
+	}
+	if fields["riddle"] != level.Riddle || fields["level_number"] != float64(3) {
+		t.Errorf("unexpected player level %s", body)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewPlantHandler(nil, nil, nil)
+
+	cases := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{"", "", true},
+		{"  3f1c9a  ", "3f1c9a", true},
+		{strings.Repeat("k", maxIdempotencyKeyLength+1), "", false},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rr)
+		c.Request, _ = http.NewRequest("POST", "/game/complete", nil)
+		c.Request.Header.Set("Idempotency-Key", tc.header)
+
+		key, ok := h.idempotencyKey(c)
+		if key != tc.want || ok != tc.ok {
+			t.Errorf("idempotencyKey(%.10q) = %q, %v; want %q, %v", tc.header, key, ok, tc.want, tc.ok)
+		}
+		if !ok && rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for a rejected key, got %d", rr.Code)
+		}
+	}
+}
+
+// errorStatus is the status a send*Error method should respond to err with.
+type errorStatus struct {
+	err  error
+	want int
+}
+
+// testErrorStatuses checks the status send responds with for each error.
+func testErrorStatuses(t *testing.T, send func(*PlantHandler, *gin.Context, error), cases []errorStatus) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewPlantHandler(nil, nil, nil)
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rr)
+		send(h, c, tc.err)
+		if rr.Code != tc.want {
+			t.Errorf("%v: got %d, want %d", tc.err, rr.Code, tc.want)
+		}
+	}
+}
+
+func TestSendCompletionError(t *testing.T) {
+	testErrorStatuses(t, (*PlantHandler).sendCompletionError, []errorStatus{
+		{infrastructure.ErrLevelAlreadyCompleted, http.StatusConflict},
+		{infrastructure.ErrLevelLocked, http.StatusForbidden},
+		{infrastructure.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+		{errors.New("connection reset"), http.StatusInternalServerError},
+	})
+}
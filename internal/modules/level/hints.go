@@ -0,0 +1,323 @@
+package level
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+// HintRequest creates or updates a hint. Content is the clue text or image
+// URL; first_letter and length hints are derived from the plant name and
+// take none. On update, omitted fields keep their current value.
+type HintRequest struct {
+	Type          infrastructure.HintType `json:"type" example:"text"`
+	Content       *string                 `json:"content"`
+	Position      *int                    `json:"position"`
+	Cost          *int                    `json:"cost"`
+	RewardPenalty *int                    `json:"reward_penalty"`
+}
+
+// UnlockHintRequest pays for the hint with a hint item from the shop
+// instead of coins when UseItem is set.
+type UnlockHintRequest struct {
+	UseItem bool `json:"use_item"`
+}
+
+// apply copies the fields set in req onto hint and checks the result.
+func (req *HintRequest) apply(hint *infrastructure.LevelHint) error {
+	if req.Type != "" {
+		hint.Type = req.Type
+	}
+	if req.Content != nil {
+		hint.Content = strings.TrimSpace(*req.Content)
+	}
+	if req.Position != nil {
+		hint.Position = *req.Position
+	}
+	if req.Cost != nil {
+		hint.Cost = *req.Cost
+	}
+	if req.RewardPenalty != nil {
+		hint.RewardPenalty = *req.RewardPenalty
+	}
+	if !hint.Type.NeedsContent() {
+		hint.Content = ""
+	}
+
+	switch {
+	case !hint.Type.Valid():
+		return errors.New("type must be one of text, image, first_letter or length")
+	case hint.Type.NeedsContent() && hint.Content == "":
+		return errors.New("content is required for text and image hints")
+	case len(hint.Content) > 1000:
+		return errors.New("content must be at most 1000 characters")
+	case hint.Position < 0:
+		return errors.New("position cannot be negative")
+	case hint.Cost < 0:
+		return errors.New("cost cannot be negative")
+	case hint.RewardPenalty < 0 || hint.RewardPenalty > 100:
+		return errors.New("reward penalty must be between 0 and 100")
+	}
+	return nil
+}
+
+// sendHintError writes the response for a failed hint unlock.
+func (h *PlantHandler) sendHintError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrNoMoreHints):
+		h.sendError(c, http.StatusNotFound, "No more hints for this level", nil)
+	case errors.Is(err, infrastructure.ErrNoHintItems):
+		h.sendError(c, http.StatusConflict, "You have no hint items", nil)
+	case errors.Is(err, infrastructure.ErrInsufficientCoins):
+		h.sendError(c, http.StatusConflict, "Not enough coins", nil)
+	case errors.Is(err, infrastructure.ErrLevelAlreadyCompleted),
+		errors.Is(err, infrastructure.ErrLevelLocked):
+		h.sendCompletionError(c, err)
+	default:
+		h.sendError(c, http.StatusInternalServerError, "Failed to unlock hint", err)
+	}
+}
+
+// levelFromParam loads the level named by the :id path parameter.
+func (h *PlantHandler) levelFromParam(c *gin.Context) (*infrastructure.Level, bool) {
+	levelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid level ID", err)
+		return nil, false
+	}
+	level, err := h.repository.GetLevelByID(uint(levelID))
+	if err != nil {
+		h.sendError(c, http.StatusNotFound, "Level not found", err)
+		return nil, false
+	}
+	return level, true
+}
+
+// GetLevelHints godoc
+// @Summary      Get a level's hints
+// @Description  Lists the level's hints in unlock order with their cost and reward penalty. Only unlocked hints include their content.
+// @Tags         Game
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id path int true "Level ID"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      401 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/me/levels/{id}/hints [get]
+// @Router       /levels/{id}/hints [get]
+func (h *PlantHandler) GetLevelHints(c *gin.Context) {
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	level, ok := h.levelFromParam(c)
+	if !ok {
+		return
+	}
+
+	hints, err := h.repository.GetHintViews(userID, level)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve hints", err)
+		return
+	}
+	penalty := 0
+	for _, hint := range hints {
+		if hint.Unlocked {
+			penalty += hint.RewardPenalty
+		}
+	}
+
+	h.sendSuccess(c, "Hints retrieved successfully", map[string]interface{}{
+		"level_id":           level.ID,
+		"level_number":       level.LevelNumber,
+		"hints":              hints,
+		"reward_after_hints": infrastructure.ReduceReward(level.Reward, penalty),
+	})
+}
+
+// UnlockHint godoc
+// @Summary      Unlock the next hint
+// @Description  Unlocks the caller's next hint for the level, paying its cost in coins or, with use_item, one hint item from their inventory. Each hint used reduces the level's reward by its reward_penalty percent.
+// @Tags         Game
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Level ID"
+// @Param        request body UnlockHintRequest false "How to pay"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      401 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      409 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/me/levels/{id}/hints/unlock [post]
+// @Router       /levels/{id}/hints/unlock [post]
+func (h *PlantHandler) UnlockHint(c *gin.Context) {
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	var req UnlockHintRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+	level, ok := h.levelFromParam(c)
+	if !ok {
+		return
+	}
+
+	hint, err := h.repository.UnlockNextHint(userID, level, req.UseItem)
+	if err != nil {
+		h.sendHintError(c, err)
+		return
+	}
+	h.sendSuccess(c, "Hint unlocked successfully", hint)
+}
+
+// GetAdminLevelHints godoc
+// @Summary      List a level's hints
+// @Description  Lists the level's hints with their content, in unlock order
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id path int true "Level ID"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/levels/{id}/hints [get]
+func (h *PlantHandler) GetAdminLevelHints(c *gin.Context) {
+	level, ok := h.levelFromParam(c)
+	if !ok {
+		return
+	}
+	hints, err := h.repository.GetLevelHints(level.ID)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve hints", err)
+		return
+	}
+	h.sendSuccess(c, "Hints retrieved successfully", hints)
+}
+
+// CreateHint godoc
+// @Summary      Create a hint
+// @Description  Adds a hint to a level. Without a position it goes after the level's last hint. reward_penalty defaults to 25 percent.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Level ID"
+// @Param        request body HintRequest true "Hint"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/levels/{id}/hints [post]
+func (h *PlantHandler) CreateHint(c *gin.Context) {
+	level, ok := h.levelFromParam(c)
+	if !ok {
+		return
+	}
+	var req HintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	hint := &infrastructure.LevelHint{LevelID: level.ID, RewardPenalty: 25}
+	if err := req.apply(hint); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid hint", err)
+		return
+	}
+	if err := h.repository.CreateHint(hint); err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to create hint", err)
+		return
+	}
+	h.sendSuccess(c, "Hint created successfully", hint)
+}
+
+// UpdateHint godoc
+// @Summary      Update a hint
+// @Description  Updates a hint. Users who already unlocked it keep what they paid and their reward penalty.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Hint ID"
+// @Param        request body HintRequest true "Fields to change"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/hints/{id} [put]
+func (h *PlantHandler) UpdateHint(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid hint ID", err)
+		return
+	}
+	hint, err := h.repository.GetHintByID(uint(id))
+	if err != nil {
+		h.sendError(c, http.StatusNotFound, "Hint not found", err)
+		return
+	}
+
+	var req HintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.apply(hint); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid hint", err)
+		return
+	}
+	if err := h.repository.UpdateHint(hint); err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to update hint", err)
+		return
+	}
+	h.sendSuccess(c, "Hint updated successfully", hint)
+}
+
+// DeleteHint godoc
+// @Summary      Delete a hint
+// @Description  Deletes a hint. Users who already unlocked it keep their unlock.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id path int true "Hint ID"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/hints/{id} [delete]
+func (h *PlantHandler) DeleteHint(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid hint ID", err)
+		return
+	}
+	if _, err := h.repository.GetHintByID(uint(id)); err != nil {
+		h.sendError(c, http.StatusNotFound, "Hint not found", err)
+		return
+	}
+	if err := h.repository.DeleteHint(uint(id)); err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to delete hint", err)
+		return
+	}
+	h.sendSuccess(c, "Hint deleted successfully", nil)
+}
@@ -0,0 +1,55 @@
+package level
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestHintRequestApply(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	strPtr := func(s string) *string { return &s }
+
+	cases := []struct {
+		name    string
+		req     HintRequest
+		wantErr bool
+	}{
+		{"text hint", HintRequest{Type: infrastructure.HintText, Content: strPtr("It climbs trees")}, false},
+		{"text hint without content", HintRequest{Type: infrastructure.HintText, Content: strPtr("  ")}, true},
+		{"image hint without url", HintRequest{Type: infrastructure.HintImage}, true},
+		{"first letter needs no content", HintRequest{Type: infrastructure.HintFirstLetter}, false},
+		{"unknown type", HintRequest{Type: "riddle"}, true},
+		{"negative cost", HintRequest{Type: infrastructure.HintLength, Cost: intPtr(-5)}, true},
+		{"penalty above 100", HintRequest{Type: infrastructure.HintLength, RewardPenalty: intPtr(101)}, true},
+	}
+	for _, tc := range cases {
+		hint := infrastructure.LevelHint{RewardPenalty: 25}
+		err := tc.req.apply(&hint)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: apply error = %v, wantErr %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	// Switching to a derived hint drops the old content.
+	hint := infrastructure.LevelHint{Type: infrastructure.HintText, Content: "It climbs trees"}
+	if err := (&HintRequest{Type: infrastructure.HintFirstLetter}).apply(&hint); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if hint.Content != "" {
+		t.Errorf("expected content to be cleared, got %q", hint.Content)
+	}
+}
+
+func TestSendHintError(t *testing.T) {
+	testErrorStatuses(t, (*PlantHandler).sendHintError, []errorStatus{
+		{infrastructure.ErrNoMoreHints, http.StatusNotFound},
+		{infrastructure.ErrNoHintItems, http.StatusConflict},
+		{infrastructure.ErrInsufficientCoins, http.StatusConflict},
+		{infrastructure.ErrLevelAlreadyCompleted, http.StatusConflict},
+		{infrastructure.ErrLevelLocked, http.StatusForbidden},
+		{fmt.Errorf("connection reset"), http.StatusInternalServerError},
+	})
+}
@@ -0,0 +1,318 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// HintType is what a hint reveals.
+type HintType string
+
+const (
+	// HintText is a written clue, held in Content.
+	HintText HintType = "text"
+	// HintImage is a picture clue; Content holds its URL.
+	HintImage HintType = "image"
+	// HintFirstLetter reveals the first letter of the plant's name.
+	HintFirstLetter HintType = "first_letter"
+	// HintLength reveals how many letters each word of the name has.
+	HintLength HintType = "length"
+)
+
+// Valid reports whether t is a known hint type.
+func (t HintType) Valid() bool {
+	switch t {
+	case HintText, HintImage, HintFirstLetter, HintLength:
+		return true
+	}
+	return false
+}
+
+// NeedsContent reports whether hints of type t are authored by hand rather
+// than derived from the level's plant name.
+func (t HintType) NeedsContent() bool {
+	return t == HintText || t == HintImage
+}
+
+// LevelHint is one of a level's hints. Players unlock them in Position
+// order, paying Cost coins each, and every hint used takes RewardPenalty
+// percent off the level's reward.
+type LevelHint struct {
+	ID            uint           `json:"id" gorm:"primaryKey" db:"id"`
+	LevelID       uint           `json:"level_id" gorm:"not null;index:idx_level_hints_level_position" db:"level_id"`
+	Position      int            `json:"position" gorm:"not null;index:idx_level_hints_level_position" db:"position"`
+	Type          HintType       `json:"type" gorm:"not null;size:32" db:"type"`
+	Content       string         `json:"content,omitempty" gorm:"size:1000" db:"content"`
+	Cost          int            `json:"cost" gorm:"not null;default:0" db:"cost"`
+	RewardPenalty int            `json:"reward_penalty" gorm:"not null;default:25" db:"reward_penalty"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (LevelHint) TableName() string {
+	return "level_hints"
+}
+
+// Reveal returns what the hint shows the player for level.
+func (h *LevelHint) Reveal(level *Level) string {
+	switch h.Type {
+	case HintFirstLetter:
+		first, _ := utf8.DecodeRuneInString(strings.TrimSpace(level.PlantName))
+		if first == utf8.RuneError {
+			return ""
+		}
+		return string(unicode.ToUpper(first))
+	case HintLength:
+		var lengths []string
+		for _, word := range strings.Fields(level.PlantName) {
+			lengths = append(lengths, fmt.Sprint(utf8.RuneCountInString(word)))
+		}
+		return strings.Join(lengths, " ")
+	default:
+		return h.Content
+	}
+}
+
+// UserHintUnlock records a hint a user unlocked. CoinsSpent and
+// RewardPenalty are copied from the hint at the time, so later edits don't
+// change what the user paid or earns.
+type UserHintUnlock struct {
+	ID            uint      `json:"id" gorm:"primaryKey" db:"id"`
+	UserID        uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_hint_unlocks_user_hint;index:idx_user_hint_unlocks_user_level" db:"user_id"`
+	LevelID       uint      `json:"level_id" gorm:"not null;index:idx_user_hint_unlocks_user_level" db:"level_id"`
+	HintID        uint      `json:"hint_id" gorm:"not null;uniqueIndex:idx_user_hint_unlocks_user_hint" db:"hint_id"`
+	CoinsSpent    int       `json:"coins_spent" gorm:"not null;default:0" db:"coins_spent"`
+	UsedItem      bool      `json:"used_item" gorm:"not null;default:false" db:"used_item"`
+	RewardPenalty int       `json:"reward_penalty" gorm:"not null;default:0" db:"reward_penalty"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+func (UserHintUnlock) TableName() string {
+	return "user_hint_unlocks"
+}
+
+// ErrNoMoreHints is returned when the user has unlocked every hint of the
+// level.
+var ErrNoMoreHints = errors.New("no more hints for this level")
+
+// ErrNoHintItems is returned when paying for a hint with a hint item the
+// user doesn't have.
+var ErrNoHintItems = errors.New("no hint items left")
+
+// HintView is a hint as shown to a player. Content is only set once the
+// hint is unlocked.
+type HintView struct {
+	ID            uint       `json:"id"`
+	Position      int        `json:"position"`
+	Type          HintType   `json:"type"`
+	Cost          int        `json:"cost"`
+	RewardPenalty int        `json:"reward_penalty"`
+	Unlocked      bool       `json:"unlocked"`
+	UnlockedAt    *time.Time `json:"unlocked_at,omitempty"`
+	Content       string     `json:"content,omitempty"`
+}
+
+func newHintView(hint *LevelHint, level *Level, unlock *UserHintUnlock) HintView {
+	view := HintView{
+		ID:            hint.ID,
+		Position:      hint.Position,
+		Type:          hint.Type,
+		Cost:          hint.Cost,
+		RewardPenalty: hint.RewardPenalty,
+	}
+	if unlock != nil {
+		view.Unlocked = true
+		view.UnlockedAt = &unlock.CreatedAt
+		view.Content = hint.Reveal(level)
+	}
+	return view
+}
+
+// ReduceReward takes penalty percent off reward, never going below zero.
+func ReduceReward(reward, penalty int) int {
+	if penalty <= 0 {
+		return reward
+	}
+	if penalty >= 100 {
+		return 0
+	}
+	return reward * (100 - penalty) / 100
+}
+
+// hintPenalty is the total reward penalty of the hints the user unlocked
+// for the level.
+func hintPenalty(tx *gorm.DB, userID, levelID uint) (int, error) {
+	var penalty int
+	err := tx.Model(&UserHintUnlock{}).
+		Where("user_id = ? AND level_id = ?", userID, levelID).
+		Select("COALESCE(SUM(reward_penalty), 0)").Scan(&penalty).Error
+	return penalty, err
+}
+
+// GetLevelHints returns the level's hints in unlock order.
+func (r *PlantRepository) GetLevelHints(levelID uint) ([]LevelHint, error) {
+	var hints []LevelHint
+	err := r.db.Where("level_id = ?", levelID).Order("position ASC, id ASC").Find(&hints).Error
+	return hints, err
+}
+
+func (r *PlantRepository) GetHintByID(id uint) (*LevelHint, error) {
+	var hint LevelHint
+	if err := r.db.First(&hint, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("hint with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return &hint, nil
+}
+
+// CreateHint adds a hint to its level. A zero Position puts it after the
+// level's last hint.
+func (r *PlantRepository) CreateHint(hint *LevelHint) error {
+	if hint.Position == 0 {
+		if err := r.db.Model(&LevelHint{}).Where("level_id = ?", hint.LevelID).
+			Select("COALESCE(MAX(position), 0) + 1").Scan(&hint.Position).Error; err != nil {
+			return err
+		}
+	}
+	return r.db.Create(hint).Error
+}
+
+func (r *PlantRepository) UpdateHint(hint *LevelHint) error {
+	return r.db.Save(hint).Error
+}
+
+// DeleteHint removes a hint. Users who unlocked it keep their unlock, so the
+// coins they paid and the reward penalty still count.
+func (r *PlantRepository) DeleteHint(id uint) error {
+	return r.db.Delete(&LevelHint{}, id).Error
+}
+
+// GetHintViews returns the level's hints for the user, with the content of
+// the ones they unlocked.
+func (r *PlantRepository) GetHintViews(userID uint, level *Level) ([]HintView, error) {
+	hints, err := r.GetLevelHints(level.ID)
+	if err != nil {
+		return nil, err
+	}
+	var unlocks []UserHintUnlock
+	if err := r.db.Where("user_id = ? AND level_id = ?", userID, level.ID).Find(&unlocks).Error; err != nil {
+		return nil, err
+	}
+	byHint := make(map[uint]*UserHintUnlock, len(unlocks))
+	for i := range unlocks {
+		byHint[unlocks[i].HintID] = &unlocks[i]
+	}
+
+	views := make([]HintView, 0, len(hints))
+	for i := range hints {
+		views = append(views, newHintView(&hints[i], level, byHint[hints[i].ID]))
+	}
+	return views, nil
+}
+
+// UnlockNextHint unlocks the user's next hint for the level. It is paid
+// with the hint's cost in coins, or with one hint item from the user's
+// inventory when useItem is set. Hints can't be unlocked for levels that
+// are locked or already completed.
+func (r *PlantRepository) UnlockNextHint(userID uint, level *Level, useItem bool) (*HintView, error) {
+	var view HintView
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Completions lock the same row, so a hint can't slip in while the
+		// level is being completed at its full reward.
+		reward, err := lockUserReward(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var completed int64
+		if err := tx.Model(&UserLevelProgress{}).
+			Where("user_id = ? AND level_id = ? AND is_completed = ?", userID, level.ID, true).
+			Count(&completed).Error; err != nil {
+			return err
+		}
+		if completed > 0 {
+			return ErrLevelAlreadyCompleted
+		}
+		unlocked, err := unlockedThrough(tx, reward.LevelReached)
+		if err != nil {
+			return err
+		}
+		if level.LevelNumber > unlocked {
+			return ErrLevelLocked
+		}
+
+		var hint LevelHint
+		err = tx.Where("level_id = ? AND id NOT IN (?)", level.ID,
+			tx.Model(&UserHintUnlock{}).Select("hint_id").Where("user_id = ?", userID)).
+			Order("position ASC, id ASC").First(&hint).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoMoreHints
+		}
+		if err != nil {
+			return err
+		}
+
+		unlock := &UserHintUnlock{
+			UserID:        userID,
+			LevelID:       level.ID,
+			HintID:        hint.ID,
+			RewardPenalty: hint.RewardPenalty,
+			CreatedAt:     time.Now().UTC(),
+		}
+		if useItem {
+			if err := useHintItem(tx, userID); err != nil {
+				return err
+			}
+			unlock.UsedItem = true
+		} else if hint.Cost > 0 {
+			if err := RecordCoinTransaction(tx, reward, &CoinTransaction{
+				Amount:         -hint.Cost,
+				Reason:         CoinReasonHint,
+				SourceType:     CoinSourceHint,
+				SourceID:       &hint.ID,
+				IdempotencyKey: CoinKey(CoinReasonHint, hint.ID),
+			}); err != nil {
+				return err
+			}
+			unlock.CoinsSpent = hint.Cost
+		}
+		if err := tx.Create(unlock).Error; err != nil {
+			return err
+		}
+
+		view = newHintView(&hint, level, unlock)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// useHintItem takes one hint item from the user's inventory.
+func useHintItem(tx *gorm.DB, userID uint) error {
+	var held UserInventoryItem
+	err := tx.Joins("JOIN shop_items ON shop_items.id = user_inventory_items.shop_item_id").
+		Where("user_inventory_items.user_id = ? AND user_inventory_items.quantity > 0 AND shop_items.type = ?", userID, ShopItemHint).
+		Order("user_inventory_items.acquired_at ASC, user_inventory_items.id ASC").
+		First(&held).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNoHintItems
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&held).Updates(map[string]interface{}{
+		"quantity":   gorm.Expr("quantity - 1"),
+		"updated_at": time.Now().UTC(),
+	}).Error
+}
@@ -0,0 +1,48 @@
+package infrastructure
+
+import "testing"
+
+func TestHintReveal(t *testing.T) {
+	level := &Level{PlantName: "monstera deliciosa"}
+
+	cases := []struct {
+		hint LevelHint
+		want string
+	}{
+		{LevelHint{Type: HintText, Content: "Its leaves have holes"}, "Its leaves have holes"},
+		{LevelHint{Type: HintImage, Content: "https://cdn.example.com/leaf.jpg"}, "https://cdn.example.com/leaf.jpg"},
+		{LevelHint{Type: HintFirstLetter}, "M"},
+		{LevelHint{Type: HintLength}, "8 9"},
+	}
+	for _, tc := range cases {
+		if got := tc.hint.Reveal(level); got != tc.want {
+			t.Errorf("%s hint reveals %q, want %q", tc.hint.Type, got, tc.want)
+		}
+	}
+
+	accented := &Level{PlantName: "Érable"}
+	if got := (&LevelHint{Type: HintLength}).Reveal(accented); got != "6" {
+		t.Errorf("length should count letters, not bytes; got %q", got)
+	}
+	if got := (&LevelHint{Type: HintFirstLetter}).Reveal(accented); got != "É" {
+		t.Errorf("first letter of %q = %q", accented.PlantName, got)
+	}
+}
+
+func TestReduceReward(t *testing.T) {
+	cases := []struct {
+		reward, penalty, want int
+	}{
+		{100, 0, 100},
+		{100, 25, 75},
+		{100, 50, 50},
+		{10, 25, 7},
+		{100, 100, 0},
+		{100, 150, 0},
+	}
+	for _, tc := range cases {
+		if got := ReduceReward(tc.reward, tc.penalty); got != tc.want {
+			t.Errorf("ReduceReward(%d, %d) = %d, want %d", tc.reward, tc.penalty, got, tc.want)
+		}
+	}
+}
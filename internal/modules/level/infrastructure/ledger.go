@@ -0,0 +1,318 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CoinReason says why a coin transaction was made.
+type CoinReason string
+
+const (
+	// CoinReasonOpeningBalance carries over a balance earned before the
+	// ledger existed.
+	CoinReasonOpeningBalance CoinReason = "opening_balance"
+	CoinReasonLevelComplete  CoinReason = "level_complete"
+	// CoinReasonAccountMerge credits the surviving account with a merged
+	// account's balance, less rewards both accounts were paid for the same
+	// level.
+	CoinReasonAccountMerge CoinReason = "account_merge"
+	// CoinReasonAccountClosed empties the ledger of an account merged into
+	// another; SourceID is the account that took it over.
+	CoinReasonAccountClosed CoinReason = "account_closed"
+	// CoinReasonAdjustment is a manual correction by an admin.
+	CoinReasonAdjustment CoinReason = "adjustment"
+	// CoinReasonReversal undoes an earlier transaction.
+	CoinReasonReversal CoinReason = "reversal"
+	// CoinReasonPurchase pays for items bought in the shop.
+	CoinReasonPurchase CoinReason = "purchase"
+	// CoinReasonHint pays for a riddle hint.
+	CoinReasonHint CoinReason = "hint"
+)
+
+// Source types name the kind of record a coin transaction is about.
+const (
+	CoinSourceLevel       = "level"
+	CoinSourceUser        = "user"
+	CoinSourceTransaction = "coin_transaction"
+	CoinSourceShopItem    = "shop_item"
+	CoinSourceHint        = "level_hint"
+)
+
+// CoinTransaction is one entry in a user's append-only coin ledger. Entries
+// are never updated or deleted; a mistake is undone by a reversal. The sum of
+// a user's entries is their balance, which is cached in
+// UserReward.TotalRewards. IdempotencyKey, when set, is unique per user and
+// stops the same credit from being applied twice.
+type CoinTransaction struct {
+	ID             uint       `json:"id" gorm:"primaryKey" db:"id"`
+	UserID         uint       `json:"user_id" gorm:"not null;index;uniqueIndex:idx_coin_transactions_idempotency" db:"user_id"`
+	Amount         int        `json:"amount" gorm:"not null" db:"amount"`
+	BalanceAfter   int        `json:"balance_after" gorm:"not null" db:"balance_after"`
+	Reason         CoinReason `json:"reason" gorm:"not null;size:32" db:"reason"`
+	SourceType     string     `json:"source_type,omitempty" gorm:"size:32" db:"source_type"`
+	SourceID       *uint      `json:"source_id,omitempty" db:"source_id"`
+	Note           string     `json:"note,omitempty" gorm:"size:255" db:"note"`
+	IdempotencyKey *string    `json:"-" gorm:"size:255;uniqueIndex:idx_coin_transactions_idempotency" db:"idempotency_key"`
+	CreatedBy      *uint      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index" db:"created_at"`
+}
+
+func (CoinTransaction) TableName() string {
+	return "coin_transactions"
+}
+
+// ErrInsufficientCoins is returned when a debit would leave a negative
+// balance.
+var ErrInsufficientCoins = errors.New("not enough coins")
+
+// ErrDuplicateCoinTransaction is returned when a transaction's idempotency
+// key was already used by the same user.
+var ErrDuplicateCoinTransaction = errors.New("coin transaction already recorded")
+
+// ErrCoinTransactionNotFound is returned when reversing a transaction that
+// does not exist.
+var ErrCoinTransactionNotFound = errors.New("coin transaction not found")
+
+// ErrAlreadyReversed is returned when reversing a transaction twice, or
+// reversing a reversal or the closing entry of a merged account.
+var ErrAlreadyReversed = errors.New("coin transaction cannot be reversed again")
+
+// RecordCoinTransaction appends entry to the ledger of reward.UserID and
+// updates the cached balance. reward must have been read in tx with a row
+// lock, as lockUserReward does. Debits that would overdraw the balance fail
+// with ErrInsufficientCoins.
+func RecordCoinTransaction(tx *gorm.DB, reward *UserReward, entry *CoinTransaction) error {
+	balance := reward.TotalRewards + entry.Amount
+	if entry.Amount < 0 && balance < 0 {
+		return ErrInsufficientCoins
+	}
+
+	if entry.IdempotencyKey != nil {
+		var count int64
+		if err := tx.Model(&CoinTransaction{}).
+			Where("user_id = ? AND idempotency_key = ?", reward.UserID, *entry.IdempotencyKey).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicateCoinTransaction
+		}
+	}
+
+	entry.UserID = reward.UserID
+	entry.BalanceAfter = balance
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	reward.TotalRewards = balance
+	return tx.Model(reward).Updates(map[string]interface{}{
+		"total_rewards": balance, "updated_at": time.Now().UTC(),
+	}).Error
+}
+
+// CloseCoinLedger empties the ledger of reward.UserID, which is being merged
+// into toUserID, and records where its balance went. reward must have been
+// read in tx with a row lock. The entries themselves stay with the merged
+// account.
+func CloseCoinLedger(tx *gorm.DB, reward *UserReward, toUserID uint) error {
+	return RecordCoinTransaction(tx, reward, &CoinTransaction{
+		Amount:         -reward.TotalRewards,
+		Reason:         CoinReasonAccountClosed,
+		SourceType:     CoinSourceUser,
+		SourceID:       &toUserID,
+		IdempotencyKey: CoinKey(CoinReasonAccountClosed, toUserID),
+	})
+}
+
+// survivingAccount follows the accounts userID was merged into and returns
+// the one that still holds its coins.
+func survivingAccount(tx *gorm.DB, userID uint) (uint, error) {
+	for {
+		var closing CoinTransaction
+		err := tx.Where("user_id = ? AND reason = ?", userID, CoinReasonAccountClosed).First(&closing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return userID, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		userID = *closing.SourceID
+	}
+}
+
+// CoinKey builds an idempotency key for a transaction about a source record.
+func CoinKey(reason CoinReason, sourceID uint) *string {
+	key := fmt.Sprintf("%s:%d", reason, sourceID)
+	return &key
+}
+
+// GetCoinTransactions returns a page of the user's ledger, newest first, and
+// the total number of entries.
+func (r *PlantRepository) GetCoinTransactions(userID uint, limit, offset int) ([]CoinTransaction, int64, error) {
+	var total int64
+	if err := r.db.Model(&CoinTransaction{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []CoinTransaction
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// AdjustCoins records a manual credit or debit by an admin.
+func (r *PlantRepository) AdjustCoins(userID uint, amount int, note string, adminID uint, idempotencyKey string) (*CoinTransaction, error) {
+	entry := &CoinTransaction{
+		Amount:     amount,
+		Reason:     CoinReasonAdjustment,
+		SourceType: CoinSourceUser,
+		SourceID:   &adminID,
+		Note:       note,
+		CreatedBy:  &adminID,
+	}
+	if idempotencyKey != "" {
+		entry.IdempotencyKey = &idempotencyKey
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		reward, err := lockUserReward(tx, userID)
+		if err != nil {
+			return err
+		}
+		return RecordCoinTransaction(tx, reward, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ReverseCoinTransaction records an entry that cancels the given one. Each
+// transaction can be reversed once, and reversals cannot be reversed. An
+// entry made before its account was merged is reversed on the account that
+// survived the merge.
+func (r *PlantRepository) ReverseCoinTransaction(transactionID uint, note string, adminID uint) (*CoinTransaction, error) {
+	var reversal *CoinTransaction
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var original CoinTransaction
+		if err := tx.First(&original, transactionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCoinTransactionNotFound
+			}
+			return err
+		}
+		if original.Reason == CoinReasonReversal || original.Reason == CoinReasonAccountClosed {
+			return ErrAlreadyReversed
+		}
+
+		owner, err := survivingAccount(tx, original.UserID)
+		if err != nil {
+			return err
+		}
+		reward, err := lockUserReward(tx, owner)
+		if err != nil {
+			return err
+		}
+		// A reversal made before a merge stays on the merged account's
+		// ledger, so the idempotency key alone does not catch it.
+		var reversed int64
+		if err := tx.Model(&CoinTransaction{}).
+			Where("reason = ? AND source_type = ? AND source_id = ?", CoinReasonReversal, CoinSourceTransaction, original.ID).
+			Count(&reversed).Error; err != nil {
+			return err
+		}
+		if reversed > 0 {
+			return ErrAlreadyReversed
+		}
+		reversal = &CoinTransaction{
+			Amount:         -original.Amount,
+			Reason:         CoinReasonReversal,
+			SourceType:     CoinSourceTransaction,
+			SourceID:       &original.ID,
+			Note:           note,
+			IdempotencyKey: CoinKey(CoinReasonReversal, original.ID),
+			CreatedBy:      &adminID,
+		}
+		err = RecordCoinTransaction(tx, reward, reversal)
+		if errors.Is(err, ErrDuplicateCoinTransaction) {
+			return ErrAlreadyReversed
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// BalanceDrift is a user whose cached balance differs from their ledger.
+type BalanceDrift struct {
+	UserID        uint `json:"user_id"`
+	CachedBalance int  `json:"cached_balance"`
+	LedgerBalance int  `json:"ledger_balance"`
+}
+
+// FindBalanceDrift returns up to limit users whose UserReward.TotalRewards
+// is not the sum of their ledger.
+func (r *PlantRepository) FindBalanceDrift(limit int) ([]BalanceDrift, error) {
+	var drift []BalanceDrift
+	err := r.db.Raw(`
+		SELECT r.user_id, r.total_rewards AS cached_balance, COALESCE(t.sum, 0) AS ledger_balance
+		FROM user_rewards r
+		LEFT JOIN (
+			SELECT user_id, SUM(amount) AS sum FROM coin_transactions GROUP BY user_id
+		) t ON t.user_id = r.user_id
+		WHERE r.deleted_at IS NULL AND r.total_rewards <> COALESCE(t.sum, 0)
+		ORDER BY r.user_id
+		LIMIT ?`, limit).Scan(&drift).Error
+	return drift, err
+}
+
+// ReconcileBalance resets the user's cached balance to the sum of their
+// ledger and returns the drift that was corrected.
+func (r *PlantRepository) ReconcileBalance(userID uint) (*BalanceDrift, error) {
+	drift := &BalanceDrift{UserID: userID}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		reward, err := lockUserReward(tx, userID)
+		if err != nil {
+			return err
+		}
+		drift.CachedBalance = reward.TotalRewards
+		if err := tx.Model(&CoinTransaction{}).Where("user_id = ?", userID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&drift.LedgerBalance).Error; err != nil {
+			return err
+		}
+		if drift.CachedBalance == drift.LedgerBalance {
+			return nil
+		}
+		return tx.Model(reward).Updates(map[string]interface{}{
+			"total_rewards": drift.LedgerBalance, "updated_at": time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// BackfillCoinLedger gives every user with coins but no ledger entries an
+// opening balance entry, so their ledger sums to their balance. It is safe
+// to run on every start.
+func BackfillCoinLedger(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO coin_transactions (user_id, amount, balance_after, reason, idempotency_key, created_at)
+		SELECT r.user_id, r.total_rewards, r.total_rewards, ?, ?, ?
+		FROM user_rewards r
+		WHERE r.deleted_at IS NULL AND r.total_rewards <> 0
+			AND NOT EXISTS (SELECT 1 FROM coin_transactions t WHERE t.user_id = r.user_id)
+		ON CONFLICT DO NOTHING`,
+		CoinReasonOpeningBalance, string(CoinReasonOpeningBalance), time.Now().UTC()).Error
+}
@@ -0,0 +1,57 @@
+package infrastructure
+
+import "gorm.io/gorm"
+
+// DedupeLevelProgress deletes duplicate user_level_progress rows so the
+// unique (user_id, level_id) index can be built. Before it existed a double
+// tap could store a level twice. Of each set the live, completed, earliest
+// row is kept. It must run before AutoMigrate.
+func DedupeLevelProgress(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&UserLevelProgress{}) {
+		return nil
+	}
+	return db.Exec(`
+		DELETE FROM user_level_progress p
+		USING (
+			SELECT id, ROW_NUMBER() OVER (
+				PARTITION BY user_id, level_id
+				ORDER BY deleted_at IS NULL DESC, is_completed DESC, completed_at ASC NULLS LAST, id ASC
+			) AS rank
+			FROM user_level_progress
+		) ranked
+		WHERE p.id = ranked.id AND ranked.rank > 1`).Error
+}
+
+// BackfillRewardEarned sets reward_earned on completions stored before the
+// column existed to the level's current reward. Run it once, right after
+// AutoMigrate adds the column.
+func BackfillRewardEarned(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE user_level_progress p SET reward_earned = l.reward
+		FROM levels l
+		WHERE l.id = p.level_id AND p.is_completed AND p.reward_earned = 0`).Error
+}
+
+// BackfillLevelReached moves level_reached on to the level after each
+// user's highest completed one. It used to name the highest level
+// completed, which now reads as that level still being the one to play and
+// leaves the next one locked. Only ever raising level_reached makes it safe
+// to run on every start.
+func BackfillLevelReached(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE user_rewards r SET level_reached = c.next
+		FROM (
+			SELECT h.user_id, COALESCE(
+				(SELECT MIN(l.level_number) FROM levels l
+				 WHERE l.deleted_at IS NULL AND l.level_number > h.highest),
+				h.highest + 1) AS next
+			FROM (
+				SELECT p.user_id, MAX(l.level_number) AS highest
+				FROM user_level_progress p
+				JOIN levels l ON l.id = p.level_id
+				WHERE p.is_completed AND p.deleted_at IS NULL
+				GROUP BY p.user_id
+			) h
+		) c
+		WHERE r.user_id = c.user_id AND r.deleted_at IS NULL AND r.level_reached < c.next`).Error
+}
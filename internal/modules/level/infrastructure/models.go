@@ -1,100 +1,152 @@
-// infrastructure/models.go
-package infrastructure
-
-import (
-	"time"
-	"gorm.io/gorm"
-)
-
-type Level struct {
-	ID          uint           `json:"id" gorm:"primaryKey" db:"id"`
-	LevelNumber int            `json:"level_number" gorm:"not null;uniqueIndex" db:"level_number"`
-	Riddle      string         `json:"riddle" gorm:"not null;size:500" db:"riddle"`
-	PlantName   string         `json:"plant_name" gorm:"not null;size:255" db:"plant_name"`
-	Reward      int            `json:"reward" gorm:"not null;default:0" db:"reward"`
-	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-}
-
-func (Level) TableName() string {
-	return "levels"
-}
-
-type UserLevelProgress struct {
-	ID          uint      `json:"id" gorm:"primaryKey" db:"id"`
-	UserID      uint      `json:"user_id" gorm:"not null;index" db:"user_id"`
-	LevelID     uint      `json:"level_id" gorm:"not null;index" db:"level_id"`
-	IsCompleted bool      `json:"is_completed" gorm:"default:false" db:"is_completed"`
-	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	
-	// Relationships
-	Level Level `json:"level,omitempty" gorm:"foreignKey:LevelID"`
-}
-
-func (UserLevelProgress) TableName() string {
-	return "user_level_progress"
-}
-
-type UserReward struct {
-	ID           uint      `json:"id" gorm:"primaryKey" db:"id"`
-	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex" db:"user_id"`
-	TotalRewards int       `json:"total_rewards" gorm:"default:0" db:"total_rewards"`
-	LevelReached int       `json:"level_reached" gorm:"default:1" db:"level_reached"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
-}
-
-func (UserReward) TableName() string {
-	return "user_rewards"
-}
-
-// GORM Hooks
-func (l *Level) BeforeCreate(tx *gorm.DB) error {
-	if l.CreatedAt.IsZero() {
-		l.CreatedAt = time.Now().UTC()
-	}
-	if l.UpdatedAt.IsZero() {
-		l.UpdatedAt = time.Now().UTC()
-	}
-	return nil
-}
-
-func (l *Level) BeforeUpdate(tx *gorm.DB) error {
-	l.UpdatedAt = time.Now().UTC()
-	return nil
-}
-
-func (ulp *UserLevelProgress) BeforeCreate(tx *gorm.DB) error {
-	if ulp.CreatedAt.IsZero() {
-		ulp.CreatedAt = time.Now().UTC()
-	}
-	if ulp.UpdatedAt.IsZero() {
-		ulp.UpdatedAt = time.Now().UTC()
-	}
-	return nil
-}
-
-func (ulp *UserLevelProgress) BeforeUpdate(tx *gorm.DB) error {
-	ulp.UpdatedAt = time.Now().UTC()
-	return nil
-}
-
-func (ur *UserReward) BeforeCreate(tx *gorm.DB) error {
-	if ur.CreatedAt.IsZero() {
-		ur.CreatedAt = time.Now().UTC()
-	}
-	if ur.UpdatedAt.IsZero() {
-		ur.UpdatedAt = time.Now().UTC()
-	}
-	return nil
-}
-
-func (ur *UserReward) BeforeUpdate(tx *gorm.DB) error {
-	ur.UpdatedAt = time.Now().UTC()
-	return nil
+// infrastructure/models.go
+package infrastructure
+
+import (
+	"strings"
+	"time"
+	"gorm.io/gorm"
+)
+
+// Level is one riddle. Its PlantName, ScientificName and each of the
+// comma-separated Aliases are all accepted as the answer.
+type Level struct {
+	ID             uint           `json:"id" gorm:"primaryKey" db:"id"`
+	LevelNumber    int            `json:"level_number" gorm:"not null;uniqueIndex" db:"level_number"`
+	Riddle         string         `json:"riddle" gorm:"not null;size:500" db:"riddle"`
+	PlantName      string         `json:"plant_name" gorm:"not null;size:255" db:"plant_name"`
+	ScientificName string         `json:"scientific_name" gorm:"size:255" db:"scientific_name"`
+	Aliases        string         `json:"aliases" gorm:"size:1000" db:"aliases"`
+	Reward         int            `json:"reward" gorm:"not null;default:0" db:"reward"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Level) TableName() string {
+	return "levels"
+}
+
+// AcceptedAnswers lists every name that solves the level.
+func (l *Level) AcceptedAnswers() []string {
+	answers := []string{l.PlantName}
+	if l.ScientificName != "" {
+		answers = append(answers, l.ScientificName)
+	}
+	for _, alias := range strings.Split(l.Aliases, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			answers = append(answers, alias)
+		}
+	}
+	return answers
+}
+
+// UserLevelProgress is a user's progress on one level; there is at most one
+// row per user and level. RewardEarned is what the completion paid, and
+// IdempotencyKey the client key it was made with, if any.
+type UserLevelProgress struct {
+	ID             uint           `json:"id" gorm:"primaryKey" db:"id"`
+	UserID         uint           `json:"user_id" gorm:"not null;index;uniqueIndex:idx_user_level_progress_user_level;uniqueIndex:idx_user_level_progress_idempotency" db:"user_id"`
+	LevelID        uint           `json:"level_id" gorm:"not null;index;uniqueIndex:idx_user_level_progress_user_level" db:"level_id"`
+	IsCompleted    bool           `json:"is_completed" gorm:"default:false" db:"is_completed"`
+	CompletedAt    *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
+	RewardEarned   int            `json:"reward_earned" gorm:"not null;default:0" db:"reward_earned"`
+	IdempotencyKey *string        `json:"-" gorm:"size:255;uniqueIndex:idx_user_level_progress_idempotency" db:"idempotency_key"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	
+	// Relationships
+	Level Level `json:"level,omitempty" gorm:"foreignKey:LevelID"`
+}
+
+func (UserLevelProgress) TableName() string {
+	return "user_level_progress"
+}
+
+// AttemptMethod is how a player tried to solve a level.
+type AttemptMethod string
+
+const (
+	// AttemptAnswer is a typed guess.
+	AttemptAnswer AttemptMethod = "answer"
+	// AttemptScan is a photo of the plant; Answer holds the prediction.
+	AttemptScan AttemptMethod = "scan"
+)
+
+// LevelAttempt is one answer or scan a player submitted for a level.
+type LevelAttempt struct {
+	ID         uint          `json:"id" gorm:"primaryKey" db:"id"`
+	UserID     uint          `json:"user_id" gorm:"not null;index:idx_level_attempts_user_level" db:"user_id"`
+	LevelID    uint          `json:"level_id" gorm:"not null;index:idx_level_attempts_user_level" db:"level_id"`
+	Method     AttemptMethod `json:"method" gorm:"not null;size:16;default:answer" db:"method"`
+	Answer     string        `json:"answer" gorm:"not null;size:255" db:"answer"`
+	Confidence *float64      `json:"confidence,omitempty" db:"confidence"`
+	IsCorrect  bool          `json:"is_correct" gorm:"not null;default:false" db:"is_correct"`
+	// RevealedAnswer is set on a wrong guess whose response showed the answer.
+	RevealedAnswer bool      `json:"revealed_answer" gorm:"not null;default:false" db:"revealed_answer"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+func (LevelAttempt) TableName() string {
+	return "level_attempts"
+}
+
+type UserReward struct {
+	ID           uint      `json:"id" gorm:"primaryKey" db:"id"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex" db:"user_id"`
+	TotalRewards int       `json:"total_rewards" gorm:"default:0" db:"total_rewards"`
+	LevelReached int       `json:"level_reached" gorm:"default:1" db:"level_reached"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (UserReward) TableName() string {
+	return "user_rewards"
+}
+
+// GORM Hooks
+func (l *Level) BeforeCreate(tx *gorm.DB) error {
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = time.Now().UTC()
+	}
+	if l.UpdatedAt.IsZero() {
+		l.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (l *Level) BeforeUpdate(tx *gorm.DB) error {
+	l.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (ulp *UserLevelProgress) BeforeCreate(tx *gorm.DB) error {
+	if ulp.CreatedAt.IsZero() {
+		ulp.CreatedAt = time.Now().UTC()
+	}
+	if ulp.UpdatedAt.IsZero() {
+		ulp.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (ulp *UserLevelProgress) BeforeUpdate(tx *gorm.DB) error {
+	ulp.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+func (ur *UserReward) BeforeCreate(tx *gorm.DB) error {
+	if ur.CreatedAt.IsZero() {
+		ur.CreatedAt = time.Now().UTC()
+	}
+	if ur.UpdatedAt.IsZero() {
+		ur.UpdatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+func (ur *UserReward) BeforeUpdate(tx *gorm.DB) error {
+	ur.UpdatedAt = time.Now().UTC()
+	return nil
 }
\ No newline at end of file
@@ -1,303 +1,525 @@
-// infrastructure/repository.go
-package infrastructure
-
-import (
-	"errors"
-	"fmt"
-	"time"
-	"gorm.io/gorm"
-)
-
-type PlantRepository struct {
-	db *gorm.DB
-}
-
-func NewPlantRepository(db *gorm.DB) *PlantRepository {
-	return &PlantRepository{db: db}
-}
-
-// Level CRUD operations
-func (r *PlantRepository) CreateLevel(level *Level) error {
-	return r.db.Create(level).Error
-}
-
-func (r *PlantRepository) GetLevelByID(id uint) (*Level, error) {
-	var level Level
-	err := r.db.First(&level, id).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("level with ID %d not found", id)
-		}
-		return nil, err
-	}
-	return &level, nil
-}
-
-// Get level by level number
-func (r *PlantRepository) GetLevelByNumber(levelNumber int) (*Level, error) {
-	var level Level
-	err := r.db.Where("level_number = ?", levelNumber).First(&level).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("level number %d not found", levelNumber)
-		}
-		return nil, err
-	}
-	return &level, nil
-}
-
-func (r *PlantRepository) GetAllLevels() ([]Level, error) {
-	var levels []Level
-	err := r.db.Order("level_number ASC").Find(&levels).Error
-	return levels, err
-}
-
-func (r *PlantRepository) UpdateLevel(level *Level) error {
-	return r.db.Save(level).Error
-}
-
-func (r *PlantRepository) DeleteLevel(id uint) error {
-	return r.db.Delete(&Level{}, id).Error
-}
-
-func (r *PlantRepository) GetLevelsCount() (int64, error) {
-	var count int64
-	err := r.db.Model(&Level{}).Count(&count).Error
-	return count, err
-}
-
-// UserLevelProgress CRUD operations  
-func (r *PlantRepository) GetUserProgress(userID uint) ([]UserLevelProgress, error) {
-	var progressList []UserLevelProgress
-	err := r.db.Where("user_id = ?", userID).
-		Preload("Level").
-		Joins("JOIN levels ON levels.id = user_level_progress.level_id").
-		Order("levels.level_number ASC").
-		Find(&progressList).Error
-	return progressList, err
-}
-
-func (r *PlantRepository) GetCompletedLevels(userID uint) ([]UserLevelProgress, error) {
-	var progressList []UserLevelProgress
-	err := r.db.Where("user_id = ? AND is_completed = ?", userID, true).
-		Preload("Level").
-		Joins("JOIN levels ON levels.id = user_level_progress.level_id").
-		Order("levels.level_number ASC").
-		Find(&progressList).Error
-	return progressList, err
-}
-
-func (r *PlantRepository) IsLevelCompleted(userID, levelID uint) bool {
-	var count int64
-	r.db.Model(&UserLevelProgress{}).
-		Where("user_id = ? AND level_id = ? AND is_completed = ?", userID, levelID, true).
-		Count(&count)
-	return count > 0
-}
-
-// Check if level is completed by level number
-func (r *PlantRepository) IsLevelCompletedByNumber(userID uint, levelNumber int) bool {
-	var count int64
-	r.db.Table("user_level_progress").
-		Joins("JOIN levels ON levels.id = user_level_progress.level_id").
-		Where("user_level_progress.user_id = ? AND levels.level_number = ? AND user_level_progress.is_completed = ?", 
-			userID, levelNumber, true).
-		Count(&count)
-	return count > 0
-}
-
-func (r *PlantRepository) CompleteLevel(userID, levelID uint) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// Check if already completed
-		var existing UserLevelProgress
-		err := tx.Where("user_id = ? AND level_id = ?", userID, levelID).First(&existing).Error
-		
-		now := time.Now().UTC()
-		
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Create new progress record
-			progress := UserLevelProgress{
-				UserID:      userID,
-				LevelID:     levelID,
-				IsCompleted: true,
-				CompletedAt: &now,
-				CreatedAt:   now,
-				UpdatedAt:   now,
-			}
-			err = tx.Create(&progress).Error
-		} else if err == nil && !existing.IsCompleted {
-			// Update existing record
-			existing.IsCompleted = true
-			existing.CompletedAt = &now
-			existing.UpdatedAt = now
-			err = tx.Save(&existing).Error
-		}
-		
-		if err != nil {
-			return err
-		}
-		
-		// Get level reward and level number
-		var level Level
-		err = tx.First(&level, levelID).Error
-		if err != nil {
-			return err
-		}
-		
-		// Update user rewards with level number
-		err = r.addRewardToUser(tx, userID, level.Reward, level.LevelNumber)
-		if err != nil {
-			return err
-		}
-		
-		return nil
-	})
-}
-
-// UserReward operations
-func (r *PlantRepository) GetOrCreateUserReward(userID uint) (*UserReward, error) {
-	var reward UserReward
-	err := r.db.Where("user_id = ?", userID).First(&reward).Error
-	
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Create new user reward record
-		reward = UserReward{
-			UserID:       userID,
-			TotalRewards: 0,
-			LevelReached: 1,
-			CreatedAt:    time.Now().UTC(),
-			UpdatedAt:    time.Now().UTC(),
-		}
-		err = r.db.Create(&reward).Error
-		if err != nil {
-			return nil, err
-		}
-	} else if err != nil {
-		return nil, err
-	}
-	
-	return &reward, nil
-}
-
-func (r *PlantRepository) addRewardToUser(tx *gorm.DB, userID uint, rewardPoints int, levelNumber int) error {
-	var userReward UserReward
-	err := tx.Where("user_id = ?", userID).First(&userReward).Error
-	
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Create new record
-		userReward = UserReward{
-			UserID:       userID,
-			TotalRewards: rewardPoints,
-			LevelReached: levelNumber,
-			CreatedAt:    time.Now().UTC(),
-			UpdatedAt:    time.Now().UTC(),
-		}
-		return tx.Create(&userReward).Error
-	} else if err != nil {
-		return err
-	}
-	
-	// Update existing record
-	userReward.TotalRewards += rewardPoints
-	if levelNumber > userReward.LevelReached {
-		userReward.LevelReached = levelNumber
-	}
-	userReward.UpdatedAt = time.Now().UTC()
-	
-	return tx.Save(&userReward).Error
-}
-
-func (r *PlantRepository) GetUserReward(userID uint) (*UserReward, error) {
-	var reward UserReward
-	err := r.db.Where("user_id = ?", userID).First(&reward).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("user reward not found for user %d", userID)
-		}
-		return nil, err
-	}
-	return &reward, nil
-}
-
-func (r *PlantRepository) UpdateUserReward(reward *UserReward) error {
-	return r.db.Save(reward).Error
-}
-
-// Get level details by level number with user completion status
-func (r *PlantRepository) GetLevelDetailsByNumber(userID uint, levelNumber int) (map[string]interface{}, error) {
-	level, err := r.GetLevelByNumber(levelNumber)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Check if user has completed this level
-	isCompleted := r.IsLevelCompletedByNumber(userID, levelNumber)
-	
-	// Get user reward to check if level is unlocked
-	userReward, err := r.GetOrCreateUserReward(userID)
-	if err != nil {
-		return nil, err
-	}
-	
-	isUnlocked := levelNumber <= userReward.LevelReached
-	
-	return map[string]interface{}{
-		"id":           level.ID,
-		"level_number": level.LevelNumber,
-		"riddle":       level.Riddle,
-		"plant_name":   level.PlantName,
-		"reward":       level.Reward,
-		"is_completed": isCompleted,
-		"is_unlocked":  isUnlocked,
-		"user_reward": map[string]interface{}{
-			"total_rewards": userReward.TotalRewards,
-			"level_reached": userReward.LevelReached,
-		},
-	}, nil
-}
-
-// Enhanced game data with level numbers
-func (r *PlantRepository) GetGameData(userID uint) (map[string]interface{}, error) {
-	// Get user rewards
-	userReward, err := r.GetOrCreateUserReward(userID)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Get all levels
-	levels, err := r.GetAllLevels()
-	if err != nil {
-		return nil, err
-	}
-	
-	// Get completed levels
-	completedLevels, err := r.GetCompletedLevels(userID)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Create a map of completed level IDs for quick lookup
-	completedMap := make(map[uint]bool)
-	for _, progress := range completedLevels {
-		completedMap[progress.LevelID] = true
-	}
-	
-	// Prepare level data with completion status
-	levelData := make([]map[string]interface{}, len(levels))
-	for i, level := range levels {
-		levelData[i] = map[string]interface{}{
-			"id":           level.ID,
-			"level_number": level.LevelNumber,
-			"reward":       level.Reward,
-			"is_completed": completedMap[level.ID],
-			"is_unlocked":  level.LevelNumber <= userReward.LevelReached,
-		}
-	}
-	
-	return map[string]interface{}{
-		"user_reward":      userReward,
-		"levels":           levelData,
-		"completed_levels": len(completedLevels),
-		"total_levels":     len(levels),
-	}, nil
+// infrastructure/repository.go
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlantRepository struct {
+	db *gorm.DB
+}
+
+func NewPlantRepository(db *gorm.DB) *PlantRepository {
+	return &PlantRepository{db: db}
+}
+
+// Level CRUD operations
+func (r *PlantRepository) CreateLevel(level *Level) error {
+	return r.db.Create(level).Error
+}
+
+func (r *PlantRepository) GetLevelByID(id uint) (*Level, error) {
+	var level Level
+	err := r.db.First(&level, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("level with ID %d not found", id)
+		}
+		return nil, err
+	}
+	return &level, nil
+}
+
+// Get level by level number
+func (r *PlantRepository) GetLevelByNumber(levelNumber int) (*Level, error) {
+	var level Level
+	err := r.db.Where("level_number = ?", levelNumber).First(&level).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("level number %d not found", levelNumber)
+		}
+		return nil, err
+	}
+	return &level, nil
+}
+
+func (r *PlantRepository) GetAllLevels() ([]Level, error) {
+	var levels []Level
+	err := r.db.Order("level_number ASC").Find(&levels).Error
+	return levels, err
+}
+
+func (r *PlantRepository) UpdateLevel(level *Level) error {
+	return r.db.Save(level).Error
+}
+
+func (r *PlantRepository) DeleteLevel(id uint) error {
+	return r.db.Delete(&Level{}, id).Error
+}
+
+func (r *PlantRepository) GetLevelsCount() (int64, error) {
+	var count int64
+	err := r.db.Model(&Level{}).Count(&count).Error
+	return count, err
+}
+
+// UserLevelProgress CRUD operations  
+func (r *PlantRepository) GetUserProgress(userID uint) ([]UserLevelProgress, error) {
+	var progressList []UserLevelProgress
+	err := r.db.Where("user_id = ?", userID).
+		Preload("Level").
+		Joins("JOIN levels ON levels.id = user_level_progress.level_id").
+		Order("levels.level_number ASC").
+		Find(&progressList).Error
+	return progressList, err
+}
+
+func (r *PlantRepository) GetCompletedLevels(userID uint) ([]UserLevelProgress, error) {
+	var progressList []UserLevelProgress
+	err := r.db.Where("user_id = ? AND is_completed = ?", userID, true).
+		Preload("Level").
+		Joins("JOIN levels ON levels.id = user_level_progress.level_id").
+		Order("levels.level_number ASC").
+		Find(&progressList).Error
+	return progressList, err
+}
+
+func (r *PlantRepository) IsLevelCompleted(userID, levelID uint) bool {
+	var count int64
+	r.db.Model(&UserLevelProgress{}).
+		Where("user_id = ? AND level_id = ? AND is_completed = ?", userID, levelID, true).
+		Count(&count)
+	return count > 0
+}
+
+// Check if level is completed by level number
+func (r *PlantRepository) IsLevelCompletedByNumber(userID uint, levelNumber int) bool {
+	var count int64
+	r.db.Table("user_level_progress").
+		Joins("JOIN levels ON levels.id = user_level_progress.level_id").
+		Where("user_level_progress.user_id = ? AND levels.level_number = ? AND user_level_progress.is_completed = ?", 
+			userID, levelNumber, true).
+		Count(&count)
+	return count > 0
+}
+
+// ErrLevelAlreadyCompleted is returned when completing a level the user has
+// already completed under a different idempotency key, or none.
+var ErrLevelAlreadyCompleted = errors.New("level already completed")
+
+// ErrLevelLocked is returned when completing a level the user has not
+// unlocked yet.
+var ErrLevelLocked = errors.New("level is locked")
+
+// ErrIdempotencyKeyReused is returned when an idempotency key that completed
+// one level is sent again to complete another.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for another level")
+
+// LevelCompletion is the result of completing a level. Replayed is set when
+// the request repeated an earlier completion's idempotency key and nothing
+// was changed.
+type LevelCompletion struct {
+	UserID      uint      `json:"user_id"`
+	LevelID     uint      `json:"level_id"`
+	LevelNumber int       `json:"level_number"`
+	Reward      int       `json:"reward"`
+	CompletedAt time.Time `json:"completed_at"`
+
+	IdempotencyKey string `json:"-"`
+	Replayed       bool   `json:"-"`
+}
+
+// CompleteLevel completes the level for the user and pays its reward. Once a
+// wrong guess has been answered with the plant's name the level pays
+// nothing, as a correct guess would. See completeLevel for how
+// idempotencyKey is used.
+func (r *PlantRepository) CompleteLevel(userID, levelID uint, idempotencyKey string) (*LevelCompletion, error) {
+	var completion *LevelCompletion
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var level Level
+		if err := tx.First(&level, levelID).Error; err != nil {
+			return err
+		}
+		var revealed int64
+		if err := tx.Model(&LevelAttempt{}).
+			Where("user_id = ? AND level_id = ? AND revealed_answer = ?", userID, levelID, true).
+			Count(&revealed).Error; err != nil {
+			return err
+		}
+		reward := level.Reward
+		if revealed > 0 {
+			reward = 0
+		}
+		var err error
+		completion, err = r.completeLevel(tx, userID, &level, reward, idempotencyKey)
+		return err
+	})
+	return completion, err
+}
+
+// completeLevel marks the level completed for the user and credits reward
+// to their coin ledger. The user's reward row is locked first, so
+// completions by the same user run one at a time, and the unique index on
+// (user_id, level_id) backs that up.
+//
+// Each hint the user unlocked for the level takes its penalty off reward.
+//
+// A level can only be completed once. If it already was and idempotencyKey
+// matches the key that completed it, the original completion is returned
+// with Replayed set; otherwise ErrLevelAlreadyCompleted is returned.
+func (r *PlantRepository) completeLevel(tx *gorm.DB, userID uint, level *Level, reward int, idempotencyKey string) (*LevelCompletion, error) {
+	userReward, err := lockUserReward(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+		var count int64
+		if err := tx.Model(&UserLevelProgress{}).
+			Where("user_id = ? AND idempotency_key = ? AND level_id <> ?", userID, idempotencyKey, level.ID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrIdempotencyKeyReused
+		}
+	}
+
+	var existing UserLevelProgress
+	err = tx.Unscoped().Where("user_id = ? AND level_id = ?", userID, level.ID).First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	found := err == nil
+
+	if found && existing.IsCompleted && !existing.DeletedAt.Valid {
+		if key != nil && existing.IdempotencyKey != nil && *existing.IdempotencyKey == *key {
+			completion := newLevelCompletion(&existing, level)
+			completion.Replayed = true
+			return completion, nil
+		}
+		return nil, ErrLevelAlreadyCompleted
+	}
+
+	unlocked, err := unlockedThrough(tx, userReward.LevelReached)
+	if err != nil {
+		return nil, err
+	}
+	if level.LevelNumber > unlocked {
+		return nil, ErrLevelLocked
+	}
+
+	if reward > 0 {
+		penalty, err := hintPenalty(tx, userID, level.ID)
+		if err != nil {
+			return nil, err
+		}
+		reward = ReduceReward(reward, penalty)
+	}
+
+	// The ledger key lets a level pay out once per user, whatever happens to
+	// the progress row.
+	if reward != 0 {
+		err := RecordCoinTransaction(tx, userReward, &CoinTransaction{
+			Amount:         reward,
+			Reason:         CoinReasonLevelComplete,
+			SourceType:     CoinSourceLevel,
+			SourceID:       &level.ID,
+			IdempotencyKey: CoinKey(CoinReasonLevelComplete, level.ID),
+		})
+		if errors.Is(err, ErrDuplicateCoinTransaction) {
+			reward = 0
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	progress := existing
+	if !found {
+		progress = UserLevelProgress{UserID: userID, LevelID: level.ID, CreatedAt: now}
+	}
+	progress.IsCompleted = true
+	progress.CompletedAt = &now
+	progress.RewardEarned = reward
+	progress.IdempotencyKey = key
+	progress.DeletedAt = gorm.DeletedAt{}
+	progress.UpdatedAt = now
+	if err := tx.Unscoped().Save(&progress).Error; err != nil {
+		return nil, err
+	}
+
+	// Unlock the level after this one. Numbers may have gaps where levels
+	// were deleted, so it is the next one that exists; after the last level
+	// it is whichever is added next.
+	next := level.LevelNumber + 1
+	if err := tx.Model(&Level{}).Where("level_number > ?", level.LevelNumber).
+		Select("COALESCE(MIN(level_number), ?)", next).Scan(&next).Error; err != nil {
+		return nil, err
+	}
+	if next > userReward.LevelReached {
+		userReward.LevelReached = next
+		if err := tx.Model(userReward).Update("level_reached", next).Error; err != nil {
+			return nil, err
+		}
+	}
+	return newLevelCompletion(&progress, level), nil
+}
+
+// unlockedThrough returns the highest level number a user whose LevelReached
+// is reached may play. LevelReached names the next level to play; if that
+// level was deleted, the next existing one takes its place.
+func unlockedThrough(db *gorm.DB, reached int) (int, error) {
+	unlocked := reached
+	err := db.Model(&Level{}).Where("level_number >= ?", reached).
+		Select("COALESCE(MIN(level_number), ?)", reached).Scan(&unlocked).Error
+	return unlocked, err
+}
+
+// UnlockedThrough is unlockedThrough for levels already loaded, sorted by
+// level number.
+func UnlockedThrough(levels []Level, reached int) int {
+	for _, level := range levels {
+		if level.LevelNumber >= reached {
+			return level.LevelNumber
+		}
+	}
+	return reached
+}
+
+// IsLevelUnlocked reports whether the user may play the level.
+func (r *PlantRepository) IsLevelUnlocked(userID uint, level *Level) (bool, error) {
+	userReward, err := r.GetOrCreateUserReward(userID)
+	if err != nil {
+		return false, err
+	}
+	unlocked, err := unlockedThrough(r.db, userReward.LevelReached)
+	return level.LevelNumber <= unlocked, err
+}
+
+func newLevelCompletion(progress *UserLevelProgress, level *Level) *LevelCompletion {
+	completion := &LevelCompletion{
+		UserID:      progress.UserID,
+		LevelID:     level.ID,
+		LevelNumber: level.LevelNumber,
+		Reward:      progress.RewardEarned,
+	}
+	if progress.IdempotencyKey != nil {
+		completion.IdempotencyKey = *progress.IdempotencyKey
+	}
+	if progress.CompletedAt != nil {
+		completion.CompletedAt = *progress.CompletedAt
+	}
+	return completion
+}
+
+// lockUserReward returns the user's reward row locked for update, creating
+// it first if needed.
+func lockUserReward(tx *gorm.DB, userID uint) (*UserReward, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UserReward{UserID: userID, LevelReached: 1}).Error; err != nil {
+		return nil, err
+	}
+	var reward UserReward
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(&reward).Error; err != nil {
+		return nil, err
+	}
+	return &reward, nil
+}
+
+// GetCompletion returns the user's completion of the level, or nil if they
+// have not completed it.
+func (r *PlantRepository) GetCompletion(userID uint, level *Level) (*LevelCompletion, error) {
+	var progress UserLevelProgress
+	err := r.db.Where("user_id = ? AND level_id = ? AND is_completed = ?", userID, level.ID, true).
+		First(&progress).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newLevelCompletion(&progress, level), nil
+}
+
+// AttemptStats summarises a player's wrong typed answers for one level.
+// RevealedAt is when a response first showed them the answer, if ever.
+type AttemptStats struct {
+	Failed     int64
+	FirstAt    *time.Time
+	RevealedAt *time.Time
+}
+
+// GetAttemptStats counts the user's wrong typed answers for the level, when
+// the first was made and when the answer was first shown. Failed scans are
+// not counted.
+func (r *PlantRepository) GetAttemptStats(userID, levelID uint) (AttemptStats, error) {
+	var stats AttemptStats
+	err := r.db.Model(&LevelAttempt{}).
+		Select("COUNT(*) AS failed, MIN(created_at) AS first_at, MIN(CASE WHEN revealed_answer THEN created_at END) AS revealed_at").
+		Where("user_id = ? AND level_id = ? AND method = ? AND is_correct = ?", userID, levelID, AttemptAnswer, false).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// RecordAttempt stores a submitted answer or scan.
+func (r *PlantRepository) RecordAttempt(attempt *LevelAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// GetCorrectAttempt returns the answer or scan that completed the level, or
+// nil if there is none, as for levels completed directly.
+func (r *PlantRepository) GetCorrectAttempt(userID, levelID uint) (*LevelAttempt, error) {
+	var attempt LevelAttempt
+	err := r.db.Where("user_id = ? AND level_id = ? AND is_correct = ?", userID, levelID, true).
+		Order("id").First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// CompleteLevelWithAttempt stores a correct answer or matching scan and
+// completes its level with the given reward, in one transaction. A replayed
+// completion stores nothing.
+func (r *PlantRepository) CompleteLevelWithAttempt(attempt *LevelAttempt, level *Level, reward int, idempotencyKey string) (*LevelCompletion, error) {
+	var completion *LevelCompletion
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		completion, err = r.completeLevel(tx, attempt.UserID, level, reward, idempotencyKey)
+		if err != nil || completion.Replayed {
+			return err
+		}
+		return tx.Create(attempt).Error
+	})
+	return completion, err
+}
+
+// UserReward operations
+func (r *PlantRepository) GetOrCreateUserReward(userID uint) (*UserReward, error) {
+	// Concurrent first requests may both try to create the row; the unique
+	// user_id makes all but one a no-op.
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&UserReward{UserID: userID, LevelReached: 1}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var reward UserReward
+	if err := r.db.Where("user_id = ?", userID).First(&reward).Error; err != nil {
+		return nil, err
+	}
+	return &reward, nil
+}
+
+func (r *PlantRepository) GetUserReward(userID uint) (*UserReward, error) {
+	var reward UserReward
+	err := r.db.Where("user_id = ?", userID).First(&reward).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user reward not found for user %d", userID)
+		}
+		return nil, err
+	}
+	return &reward, nil
+}
+
+// Get level details by level number with user completion status
+func (r *PlantRepository) GetLevelDetailsByNumber(userID uint, levelNumber int) (map[string]interface{}, error) {
+	level, err := r.GetLevelByNumber(levelNumber)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Check if user has completed this level
+	isCompleted := r.IsLevelCompletedByNumber(userID, levelNumber)
+	
+	// Get user reward to check if level is unlocked
+	userReward, err := r.GetOrCreateUserReward(userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	unlocked, err := unlockedThrough(r.db, userReward.LevelReached)
+	if err != nil {
+		return nil, err
+	}
+	isUnlocked := levelNumber <= unlocked
+	
+	details := map[string]interface{}{
+		"id":           level.ID,
+		"level_number": level.LevelNumber,
+		"riddle":       level.Riddle,
+		"reward":       level.Reward,
+		"is_completed": isCompleted,
+		"is_unlocked":  isUnlocked,
+		"user_reward": map[string]interface{}{
+			"total_rewards": userReward.TotalRewards,
+			"level_reached": userReward.LevelReached,
+		},
+	}
+	// The plant name is the riddle's answer, so players only see it once
+	// they have solved the level.
+	if isCompleted {
+		details["plant_name"] = level.PlantName
+	}
+	return details, nil
+}
+
+// Enhanced game data with level numbers
+func (r *PlantRepository) GetGameData(userID uint) (map[string]interface{}, error) {
+	// Get user rewards
+	userReward, err := r.GetOrCreateUserReward(userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Get all levels
+	levels, err := r.GetAllLevels()
+	if err != nil {
+		return nil, err
+	}
+	
+	// Get completed levels
+	completedLevels, err := r.GetCompletedLevels(userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Create a map of completed level IDs for quick lookup
+	completedMap := make(map[uint]bool)
+	for _, progress := range completedLevels {
+		completedMap[progress.LevelID] = true
+	}
+	
+	unlocked := UnlockedThrough(levels, userReward.LevelReached)
+
+	// Prepare level data with completion status
+	levelData := make([]map[string]interface{}, len(levels))
+	for i, level := range levels {
+		levelData[i] = map[string]interface{}{
+			"id":           level.ID,
+			"level_number": level.LevelNumber,
+			"reward":       level.Reward,
+			"is_completed": completedMap[level.ID],
+			"is_unlocked":  level.LevelNumber <= unlocked,
+		}
+	}
+	
+	return map[string]interface{}{
+		"user_reward":      userReward,
+		"levels":           levelData,
+		"completed_levels": len(completedLevels),
+		"total_levels":     len(levels),
+	}, nil
 }
\ No newline at end of file
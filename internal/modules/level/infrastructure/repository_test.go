@@ -0,0 +1,29 @@
+package infrastructure
+
+import "testing"
+
+func TestUnlockedThrough(t *testing.T) {
+	// Level 3 was deleted.
+	levels := []Level{{LevelNumber: 1}, {LevelNumber: 2}, {LevelNumber: 4}, {LevelNumber: 5}}
+
+	cases := []struct {
+		reached int
+		want    int
+	}{
+		{1, 1},
+		{2, 2},
+		{3, 4},
+		{4, 4},
+		{6, 6},
+	}
+	for _, tc := range cases {
+		if got := UnlockedThrough(levels, tc.reached); got != tc.want {
+			t.Errorf("UnlockedThrough(reached %d) = %d, want %d", tc.reached, got, tc.want)
+		}
+	}
+
+	// Without level 1 the first existing level is open to new players.
+	if got := UnlockedThrough(levels[1:], 1); got != 2 {
+		t.Errorf("expected level 2 to be open, got %d", got)
+	}
+}
@@ -0,0 +1,263 @@
+package infrastructure
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ShopItemType is the kind of thing a shop item is.
+type ShopItemType string
+
+const (
+	ShopItemHint         ShopItemType = "hint"
+	ShopItemBadge        ShopItemType = "badge"
+	ShopItemDecoration   ShopItemType = "decoration"
+	ShopItemStreakFreeze ShopItemType = "streak_freeze"
+)
+
+// Valid reports whether t is a known item type.
+func (t ShopItemType) Valid() bool {
+	switch t {
+	case ShopItemHint, ShopItemBadge, ShopItemDecoration, ShopItemStreakFreeze:
+		return true
+	}
+	return false
+}
+
+// Stackable reports whether a user can hold more than one of the item.
+// Hints and streak freezes are used up; badges and decorations are owned once.
+func (t ShopItemType) Stackable() bool {
+	return t == ShopItemHint || t == ShopItemStreakFreeze
+}
+
+// ShopItem is an entry in the shop catalog. Inactive items are hidden from
+// players but stay in the inventories of those who bought them.
+type ShopItem struct {
+	ID          uint           `json:"id" gorm:"primaryKey" db:"id"`
+	Name        string         `json:"name" gorm:"not null;size:100" db:"name"`
+	Description string         `json:"description" gorm:"size:500" db:"description"`
+	Type        ShopItemType   `json:"type" gorm:"not null;size:32;index" db:"type"`
+	Price       int            `json:"price" gorm:"not null;default:0" db:"price"`
+	ImageURL    string         `json:"image_url,omitempty" gorm:"size:500" db:"image_url"`
+	IsActive    bool           `json:"is_active" gorm:"not null;default:true" db:"is_active"`
+	SortOrder   int            `json:"sort_order" gorm:"not null;default:0" db:"sort_order"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (ShopItem) TableName() string {
+	return "shop_items"
+}
+
+// UserInventoryItem is how many of a shop item a user holds; there is at
+// most one row per user and item.
+type UserInventoryItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey" db:"id"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_inventory_user_item" db:"user_id"`
+	ShopItemID uint      `json:"shop_item_id" gorm:"not null;uniqueIndex:idx_user_inventory_user_item" db:"shop_item_id"`
+	Quantity   int       `json:"quantity" gorm:"not null;default:0" db:"quantity"`
+	AcquiredAt time.Time `json:"acquired_at" db:"acquired_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+
+	// Relationships
+	ShopItem ShopItem `json:"item" gorm:"foreignKey:ShopItemID"`
+}
+
+func (UserInventoryItem) TableName() string {
+	return "user_inventory_items"
+}
+
+// ErrShopItemNotFound is returned for items that don't exist or are not on
+// sale.
+var ErrShopItemNotFound = errors.New("shop item not found")
+
+// ErrItemAlreadyOwned is returned when buying a non-stackable item the user
+// already has.
+var ErrItemAlreadyOwned = errors.New("item already owned")
+
+// ErrItemNotStackable is returned when buying more than one of an item that
+// can only be owned once.
+var ErrItemNotStackable = errors.New("item can only be owned once")
+
+// ErrPurchaseKeyReused is returned when an idempotency key that bought one
+// item is sent again to buy another.
+var ErrPurchaseKeyReused = errors.New("idempotency key was already used for another purchase")
+
+// Purchase is the result of buying a shop item. Replayed is set when the
+// request repeated an earlier purchase's idempotency key and nothing was
+// bought.
+type Purchase struct {
+	Item        ShopItem           `json:"item"`
+	Quantity    int                `json:"quantity"`
+	Cost        int                `json:"cost"`
+	Balance     int                `json:"balance"`
+	Transaction *CoinTransaction   `json:"transaction"`
+	Inventory   *UserInventoryItem `json:"inventory"`
+
+	Replayed bool `json:"-"`
+}
+
+// GetShopItems returns the catalog in display order. Inactive items are
+// only included for admins.
+func (r *PlantRepository) GetShopItems(includeInactive bool) ([]ShopItem, error) {
+	query := r.db.Order("sort_order ASC, price ASC, id ASC")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+	var items []ShopItem
+	err := query.Find(&items).Error
+	return items, err
+}
+
+func (r *PlantRepository) GetShopItemByID(id uint) (*ShopItem, error) {
+	var item ShopItem
+	if err := r.db.First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShopItemNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *PlantRepository) CreateShopItem(item *ShopItem) error {
+	return r.db.Create(item).Error
+}
+
+func (r *PlantRepository) UpdateShopItem(item *ShopItem) error {
+	return r.db.Save(item).Error
+}
+
+// DeleteShopItem takes the item out of the catalog. Inventories keep it.
+func (r *PlantRepository) DeleteShopItem(id uint) error {
+	return r.db.Delete(&ShopItem{}, id).Error
+}
+
+// PurchaseItem debits the price of quantity items from the user's coins and
+// adds them to their inventory in one transaction. The debit cannot overdraw
+// the balance.
+//
+// A retry that repeats idempotencyKey for the same item returns the original
+// purchase with Replayed set instead of charging twice; for another item it
+// fails with ErrPurchaseKeyReused.
+func (r *PlantRepository) PurchaseItem(userID, itemID uint, quantity int, idempotencyKey string) (*Purchase, error) {
+	purchase := &Purchase{Quantity: quantity}
+	var key *string
+	if idempotencyKey != "" {
+		k := string(CoinReasonPurchase) + ":" + idempotencyKey
+		key = &k
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the balance first serialises the user's purchases, so two
+		// taps cannot both buy a badge.
+		reward, err := lockUserReward(tx, userID)
+		if err != nil {
+			return err
+		}
+		if key != nil {
+			var original CoinTransaction
+			err := tx.Where("user_id = ? AND idempotency_key = ?", userID, *key).First(&original).Error
+			if err == nil {
+				return replayPurchase(tx, purchase, &original, itemID)
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		if err := tx.Where("is_active = ?", true).First(&purchase.Item, itemID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShopItemNotFound
+			}
+			return err
+		}
+		if !purchase.Item.Type.Stackable() && quantity != 1 {
+			return ErrItemNotStackable
+		}
+		if !purchase.Item.Type.Stackable() {
+			var owned int64
+			if err := tx.Model(&UserInventoryItem{}).
+				Where("user_id = ? AND shop_item_id = ? AND quantity > 0", userID, itemID).
+				Count(&owned).Error; err != nil {
+				return err
+			}
+			if owned > 0 {
+				return ErrItemAlreadyOwned
+			}
+		}
+
+		purchase.Cost = purchase.Item.Price * quantity
+		purchase.Transaction = &CoinTransaction{
+			Amount:         -purchase.Cost,
+			Reason:         CoinReasonPurchase,
+			SourceType:     CoinSourceShopItem,
+			SourceID:       &purchase.Item.ID,
+			IdempotencyKey: key,
+		}
+		if err := RecordCoinTransaction(tx, reward, purchase.Transaction); err != nil {
+			return err
+		}
+		purchase.Balance = reward.TotalRewards
+
+		now := time.Now().UTC()
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "shop_item_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"quantity":   gorm.Expr("user_inventory_items.quantity + EXCLUDED.quantity"),
+				"updated_at": now,
+			}),
+		}).Create(&UserInventoryItem{
+			UserID:     userID,
+			ShopItemID: itemID,
+			Quantity:   quantity,
+			AcquiredAt: now,
+			UpdatedAt:  now,
+		}).Error; err != nil {
+			return err
+		}
+
+		purchase.Inventory = &UserInventoryItem{}
+		return tx.Where("user_id = ? AND shop_item_id = ?", userID, itemID).First(purchase.Inventory).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	purchase.Inventory.ShopItem = purchase.Item
+	return purchase, nil
+}
+
+// replayPurchase fills purchase from the ledger entry that paid for an
+// earlier purchase with the same idempotency key, as it was returned then.
+// The inventory is what the user holds now.
+func replayPurchase(tx *gorm.DB, purchase *Purchase, original *CoinTransaction, itemID uint) error {
+	if original.Reason != CoinReasonPurchase || original.SourceID == nil || *original.SourceID != itemID {
+		return ErrPurchaseKeyReused
+	}
+	if err := tx.Unscoped().First(&purchase.Item, itemID).Error; err != nil {
+		return err
+	}
+	purchase.Cost = -original.Amount
+	if purchase.Item.Price > 0 {
+		purchase.Quantity = purchase.Cost / purchase.Item.Price
+	}
+	purchase.Balance = original.BalanceAfter
+	purchase.Transaction = original
+	purchase.Replayed = true
+	purchase.Inventory = &UserInventoryItem{}
+	return tx.Where("user_id = ? AND shop_item_id = ?", original.UserID, itemID).First(purchase.Inventory).Error
+}
+
+// GetInventory returns the items the user holds, including ones that have
+// since been taken out of the catalog.
+func (r *PlantRepository) GetInventory(userID uint) ([]UserInventoryItem, error) {
+	var items []UserInventoryItem
+	err := r.db.Preload("ShopItem", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ? AND quantity > 0", userID).
+		Order("acquired_at ASC, id ASC").
+		Find(&items).Error
+	return items, err
+}
@@ -0,0 +1,229 @@
+package level
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/env"
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+// maxScanImageBytes caps the photo uploaded to verify a level.
+const maxScanImageBytes = 10 << 20
+
+var errScanImageTooLarge = fmt.Errorf("image must be at most %d MB", maxScanImageBytes>>20)
+
+// PlantScanner identifies the plant in a photo, returning the model's label
+// and its confidence between 0 and 1.
+type PlantScanner interface {
+	Identify(image []byte) (prediction string, confidence float64, err error)
+}
+
+// scanMinConfidence is the confidence SCAN_MIN_CONFIDENCE a scan needs to
+// count as the level's plant.
+func scanMinConfidence() float64 {
+	return env.Float("SCAN_MIN_CONFIDENCE", 0.7)
+}
+
+// scanMatches reports whether the model's label names one of the level's
+// accepted answers. Labels come from a fixed list, so no typos are allowed.
+func scanMatches(prediction string, accepted []string) bool {
+	label := strings.ReplaceAll(normalizeAnswer(prediction), " ", "")
+	if label == "" {
+		return false
+	}
+	for _, answer := range accepted {
+		if strings.ReplaceAll(normalizeAnswer(answer), " ", "") == label {
+			return true
+		}
+	}
+	return false
+}
+
+// readScanImage reads the uploaded "file" form field, returning
+// errScanImageTooLarge rather than a truncated image when it is over the cap.
+func readScanImage(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxScanImageBytes+(1<<20))
+	file, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errScanImageTooLarge
+		}
+		return nil, err
+	}
+	opened, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer opened.Close()
+	data, err := io.ReadAll(io.LimitReader(opened, maxScanImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxScanImageBytes {
+		return nil, errScanImageTooLarge
+	}
+	return data, nil
+}
+
+// VerifyLevelByScan godoc
+// @Summary      Complete a level by scanning its plant
+// @Description  Runs the plant model on a photo taken for the level. If the prediction names the level's plant with at least SCAN_MIN_CONFIDENCE, the level is completed and its reward paid in one transaction. Every scan is recorded; failed scans do not count towards revealing the answer.
+// @Tags         Game
+// @Security     ApiKeyAuth
+// @Accept       mpfd
+// @Produce      json
+// @Param        id path int true "Level ID"
+// @Param        Idempotency-Key header string false "Client key; a retry with the same key returns the original result"
+// @Param        file formData file true "Photo of the plant"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      401 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      409 {object} Response
+// @Failure      413 {object} Response
+// @Failure      422 {object} Response
+// @Failure      500 {object} Response
+// @Failure      503 {object} Response
+// @Router       /game/me/levels/{id}/scan [post]
+// @Router       /levels/{id}/scan [post]
+func (h *PlantHandler) VerifyLevelByScan(c *gin.Context) {
+	if h.scanner == nil {
+		h.sendError(c, http.StatusServiceUnavailable, "Plant scanning is unavailable", nil)
+		return
+	}
+
+	levelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid level ID", err)
+		return
+	}
+
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	key, ok := h.idempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	level, err := h.repository.GetLevelByID(uint(levelID))
+	if err != nil {
+		h.sendError(c, http.StatusNotFound, "Level not found", err)
+		return
+	}
+	completion, ok := h.checkPlayable(c, userID, level, key)
+	if !ok {
+		return
+	}
+	if completion != nil {
+		attempt, err := h.repository.GetCorrectAttempt(userID, level.ID)
+		if err != nil {
+			h.sendError(c, http.StatusInternalServerError, "Failed to check level progress", err)
+			return
+		}
+		h.sendSuccess(c, "Level completed successfully", scanCompletedData(completion, attempt))
+		return
+	}
+
+	image, err := readScanImage(c)
+	if errors.Is(err, errScanImageTooLarge) {
+		h.sendError(c, http.StatusRequestEntityTooLarge, "Image too large", err)
+		return
+	}
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "No image uploaded", err)
+		return
+	}
+	if len(image) == 0 {
+		h.sendError(c, http.StatusBadRequest, "No image uploaded", nil)
+		return
+	}
+
+	prediction, confidence, err := h.scanner.Identify(image)
+	if err != nil {
+		log.Printf("Scan for level %d failed: %v", level.ID, err)
+		h.sendError(c, http.StatusInternalServerError, "Failed to scan image", nil)
+		return
+	}
+
+	attempt := &infrastructure.LevelAttempt{
+		UserID:     userID,
+		LevelID:    level.ID,
+		Method:     infrastructure.AttemptScan,
+		Answer:     truncate(prediction, 255),
+		Confidence: &confidence,
+		IsCorrect:  confidence >= scanMinConfidence() && scanMatches(prediction, level.AcceptedAnswers()),
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if !attempt.IsCorrect {
+		if err := h.repository.RecordAttempt(attempt); err != nil {
+			h.sendError(c, http.StatusInternalServerError, "Failed to record scan", err)
+			return
+		}
+		h.sendSuccess(c, "The scanned plant doesn't match this level", map[string]interface{}{
+			"level_id":        level.ID,
+			"level_number":    level.LevelNumber,
+			"prediction":      prediction,
+			"confidence":      confidence,
+			"matched":         false,
+			"level_completed": false,
+		})
+		return
+	}
+
+	completion, err = h.repository.CompleteLevelWithAttempt(attempt, level, level.Reward, key)
+	if err != nil {
+		h.sendCompletionError(c, err)
+		return
+	}
+	if completion.Replayed {
+		// A concurrent retry won; answer with the scan that completed the level.
+		if attempt, err = h.repository.GetCorrectAttempt(userID, level.ID); err != nil {
+			h.sendError(c, http.StatusInternalServerError, "Failed to check level progress", err)
+			return
+		}
+	} else {
+		h.notifyLevelComplete(userID, level, completion.Reward)
+	}
+
+	h.sendSuccess(c, "Level completed successfully", scanCompletedData(completion, attempt))
+}
+
+// scanCompletedData is the response for a scan that completed its level.
+func scanCompletedData(completion *infrastructure.LevelCompletion, attempt *infrastructure.LevelAttempt) map[string]interface{} {
+	data := map[string]interface{}{
+		"user_id":         completion.UserID,
+		"level_id":        completion.LevelID,
+		"level_number":    completion.LevelNumber,
+		"reward":          completion.Reward,
+		"completed_at":    completion.CompletedAt,
+		"matched":         true,
+		"level_completed": true,
+	}
+	if attempt != nil {
+		data["prediction"] = attempt.Answer
+		data["confidence"] = attempt.Confidence
+	}
+	return data
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package level
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestScanMatches(t *testing.T) {
+	accepted := []string{"Scarlet sage", "Salvia splendens"}
+
+	for _, label := range []string{"Scarlet Sage", "scarlet-sage", "Salvia Splendens"} {
+		if !scanMatches(label, accepted) {
+			t.Errorf("expected %q to match", label)
+		}
+	}
+	for _, label := range []string{"Marigold", "Scarlet Sag", ""} {
+		if scanMatches(label, accepted) {
+			t.Errorf("expected %q not to match", label)
+		}
+	}
+}
+
+func TestVerifyLevelByScanWithoutScanner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/levels/:id/scan", NewPlantHandler(nil, nil, nil).VerifyLevelByScan)
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/levels/1/scan", nil)
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a scanner, got %d", rr.Code)
+	}
+}
+
+func scanUploadContext(t *testing.T, size int) *gin.Context {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "plant.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte{0xff}, size))
+	form.Close()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/levels/1/scan", &body)
+	c.Request.Header.Set("Content-Type", form.FormDataContentType())
+	return c
+}
+
+func TestReadScanImage(t *testing.T) {
+	data, err := readScanImage(scanUploadContext(t, maxScanImageBytes))
+	if err != nil || len(data) != maxScanImageBytes {
+		t.Fatalf("expected the full %d byte image, got %d bytes and %v", maxScanImageBytes, len(data), err)
+	}
+
+	for _, size := range []int{maxScanImageBytes + 1, maxScanImageBytes + 2<<20} {
+		if _, err := readScanImage(scanUploadContext(t, size)); err != errScanImageTooLarge {
+			t.Errorf("expected errScanImageTooLarge for %d bytes, got %v", size, err)
+		}
+	}
+}
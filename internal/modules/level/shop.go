@@ -0,0 +1,313 @@
+package level
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+// maxPurchaseQuantity caps how many of a stackable item one purchase buys.
+const maxPurchaseQuantity = 99
+
+// ShopItemRequest creates or updates a catalog item. On update, omitted
+// fields keep their current value.
+type ShopItemRequest struct {
+	Name        string                      `json:"name"`
+	Description *string                     `json:"description"`
+	Type        infrastructure.ShopItemType `json:"type" example:"hint"`
+	Price       *int                        `json:"price"`
+	ImageURL    *string                     `json:"image_url"`
+	IsActive    *bool                       `json:"is_active"`
+	SortOrder   *int                        `json:"sort_order"`
+}
+
+// PurchaseRequest buys Quantity (default 1) of a shop item.
+type PurchaseRequest struct {
+	ItemID   uint `json:"item_id" binding:"required"`
+	Quantity int  `json:"quantity"`
+}
+
+// apply copies the fields set in req onto item and checks the result.
+func (req *ShopItemRequest) apply(item *infrastructure.ShopItem) error {
+	if name := strings.TrimSpace(req.Name); name != "" {
+		item.Name = name
+	}
+	if req.Description != nil {
+		item.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Type != "" {
+		item.Type = req.Type
+	}
+	if req.Price != nil {
+		item.Price = *req.Price
+	}
+	if req.ImageURL != nil {
+		item.ImageURL = strings.TrimSpace(*req.ImageURL)
+	}
+	if req.IsActive != nil {
+		item.IsActive = *req.IsActive
+	}
+	if req.SortOrder != nil {
+		item.SortOrder = *req.SortOrder
+	}
+
+	switch {
+	case item.Name == "":
+		return errors.New("name cannot be empty")
+	case len(item.Name) > 100:
+		return errors.New("name must be at most 100 characters")
+	case len(item.Description) > 500:
+		return errors.New("description must be at most 500 characters")
+	case !item.Type.Valid():
+		return errors.New("type must be one of hint, badge, decoration or streak_freeze")
+	case item.Price < 0:
+		return errors.New("price cannot be negative")
+	}
+	return nil
+}
+
+// sendShopError writes the response for a failed purchase.
+func (h *PlantHandler) sendShopError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, infrastructure.ErrShopItemNotFound):
+		h.sendError(c, http.StatusNotFound, "Item not found", nil)
+	case errors.Is(err, infrastructure.ErrItemAlreadyOwned):
+		h.sendError(c, http.StatusConflict, "You already own this item", nil)
+	case errors.Is(err, infrastructure.ErrItemNotStackable):
+		h.sendError(c, http.StatusBadRequest, "This item can only be bought once", nil)
+	case errors.Is(err, infrastructure.ErrPurchaseKeyReused):
+		h.sendError(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for another purchase", nil)
+	default:
+		h.sendCoinError(c, err)
+	}
+}
+
+// GetShopItems godoc
+// @Summary      List shop items
+// @Description  Lists the items on sale, in display order.
+// @Tags         Shop
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200 {object} Response
+// @Failure      401 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/shop/items [get]
+func (h *PlantHandler) GetShopItems(c *gin.Context) {
+	items, err := h.repository.GetShopItems(false)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve shop items", err)
+		return
+	}
+	h.sendSuccess(c, "Shop items retrieved successfully", items)
+}
+
+// PurchaseShopItem godoc
+// @Summary      Buy a shop item
+// @Description  Debits the item's price from the caller's coins and adds it to their inventory in one transaction. Hints and streak freezes can be bought in quantity; badges and decorations once.
+// @Tags         Shop
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key header string false "Client key; a retry with the same key returns the original purchase instead of charging twice"
+// @Param        request body PurchaseRequest true "Item and quantity"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      401 {object} Response
+// @Failure      404 {object} Response
+// @Failure      409 {object} Response
+// @Failure      422 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/me/shop/purchase [post]
+func (h *PlantHandler) PurchaseShopItem(c *gin.Context) {
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	var req PurchaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 0 || req.Quantity > maxPurchaseQuantity {
+		h.sendError(c, http.StatusBadRequest, "Quantity must be between 1 and "+strconv.Itoa(maxPurchaseQuantity), nil)
+		return
+	}
+	key, ok := h.idempotencyKey(c)
+	if !ok {
+		return
+	}
+
+	purchase, err := h.repository.PurchaseItem(userID, req.ItemID, req.Quantity, key)
+	if err != nil {
+		h.sendShopError(c, err)
+		return
+	}
+
+	if h.notificationService != nil && !purchase.Replayed {
+		if err := h.notificationService.GenerateShopPurchaseNotification(userID, purchase.Item.Name, purchase.Quantity, purchase.Cost); err != nil {
+			log.Printf("Failed to generate purchase notification: %v", err)
+		}
+	}
+
+	h.sendSuccess(c, "Item purchased successfully", purchase)
+}
+
+// GetInventory godoc
+// @Summary      Get inventory
+// @Description  Lists the shop items the caller holds and how many of each.
+// @Tags         Shop
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200 {object} Response
+// @Failure      401 {object} Response
+// @Failure      500 {object} Response
+// @Router       /game/me/inventory [get]
+func (h *PlantHandler) GetInventory(c *gin.Context) {
+	userID, ok := h.resolveActingUser(c, 0)
+	if !ok {
+		return
+	}
+	items, err := h.repository.GetInventory(userID)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve inventory", err)
+		return
+	}
+	h.sendSuccess(c, "Inventory retrieved successfully", items)
+}
+
+// GetAllShopItems godoc
+// @Summary      List all shop items
+// @Description  Lists the whole catalog, including items that are not on sale. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200 {object} Response
+// @Failure      403 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/shop/items [get]
+func (h *PlantHandler) GetAllShopItems(c *gin.Context) {
+	items, err := h.repository.GetShopItems(true)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to retrieve shop items", err)
+		return
+	}
+	h.sendSuccess(c, "Shop items retrieved successfully", items)
+}
+
+// CreateShopItem godoc
+// @Summary      Create a shop item
+// @Description  Adds an item to the catalog. Name, type and price are required; items are on sale unless is_active is false. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body ShopItemRequest true "Item"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/shop/items [post]
+func (h *PlantHandler) CreateShopItem(c *gin.Context) {
+	var req ShopItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Price == nil {
+		h.sendError(c, http.StatusBadRequest, "Price is required", nil)
+		return
+	}
+
+	item := &infrastructure.ShopItem{IsActive: true}
+	if err := req.apply(item); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid shop item", err)
+		return
+	}
+	if err := h.repository.CreateShopItem(item); err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to create shop item", err)
+		return
+	}
+	h.sendSuccess(c, "Shop item created successfully", item)
+}
+
+// UpdateShopItem godoc
+// @Summary      Update a shop item
+// @Description  Updates a catalog item. Price changes don't affect past purchases. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id path int true "Shop item ID"
+// @Param        request body ShopItemRequest true "Fields to change"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/shop/items/{id} [put]
+func (h *PlantHandler) UpdateShopItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid item ID", err)
+		return
+	}
+	item, err := h.repository.GetShopItemByID(uint(id))
+	if err != nil {
+		h.sendError(c, http.StatusNotFound, "Item not found", err)
+		return
+	}
+
+	var req ShopItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.apply(item); err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid shop item", err)
+		return
+	}
+	if err := h.repository.UpdateShopItem(item); err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to update shop item", err)
+		return
+	}
+	h.sendSuccess(c, "Shop item updated successfully", item)
+}
+
+// DeleteShopItem godoc
+// @Summary      Delete a shop item
+// @Description  Removes an item from the catalog. Players who bought it keep it. Admin only.
+// @Tags         Admin
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id path int true "Shop item ID"
+// @Success      200 {object} Response
+// @Failure      400 {object} Response
+// @Failure      403 {object} Response
+// @Failure      404 {object} Response
+// @Failure      500 {object} Response
+// @Router       /admin/shop/items/{id} [delete]
+func (h *PlantHandler) DeleteShopItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, "Invalid item ID", err)
+		return
+	}
+	if _, err := h.repository.GetShopItemByID(uint(id)); err != nil {
+		h.sendError(c, http.StatusNotFound, "Item not found", err)
+		return
+	}
+	if err := h.repository.DeleteShopItem(uint(id)); err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to delete shop item", err)
+		return
+	}
+	h.sendSuccess(c, "Shop item deleted successfully", nil)
+}
@@ -0,0 +1,78 @@
+package level
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"plantgo-backend/internal/modules/level/infrastructure"
+)
+
+func TestShopItemRequestApply(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+	strPtr := func(s string) *string { return &s }
+
+	existing := infrastructure.ShopItem{Name: "Hint", Type: infrastructure.ShopItemHint, Price: 25, IsActive: true}
+
+	cases := []struct {
+		name    string
+		req     ShopItemRequest
+		wantErr bool
+		check   func(infrastructure.ShopItem) bool
+	}{
+		{"empty request keeps item", ShopItemRequest{}, false,
+			func(i infrastructure.ShopItem) bool { return i == existing }},
+		{"updates price and trims description", ShopItemRequest{Price: intPtr(40), Description: strPtr("  Reveals a clue ")}, false,
+			func(i infrastructure.ShopItem) bool { return i.Price == 40 && i.Description == "Reveals a clue" }},
+		{"free item allowed", ShopItemRequest{Price: intPtr(0)}, false,
+			func(i infrastructure.ShopItem) bool { return i.Price == 0 }},
+		{"negative price", ShopItemRequest{Price: intPtr(-1)}, true, nil},
+		{"unknown type", ShopItemRequest{Type: "pet"}, true, nil},
+		{"name too long", ShopItemRequest{Name: strings.Repeat("n", 101)}, true, nil},
+	}
+	for _, tc := range cases {
+		item := existing
+		err := tc.req.apply(&item)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: apply error = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if tc.check != nil && !tc.check(item) {
+			t.Errorf("%s: unexpected item %+v", tc.name, item)
+		}
+	}
+
+	var blank infrastructure.ShopItem
+	if err := (&ShopItemRequest{Type: infrastructure.ShopItemBadge, Price: intPtr(10)}).apply(&blank); err == nil {
+		t.Error("expected an item without a name to be rejected")
+	}
+}
+
+func TestShopItemTypeStackable(t *testing.T) {
+	stackable := map[infrastructure.ShopItemType]bool{
+		infrastructure.ShopItemHint:         true,
+		infrastructure.ShopItemStreakFreeze: true,
+		infrastructure.ShopItemBadge:        false,
+		infrastructure.ShopItemDecoration:   false,
+	}
+	for itemType, want := range stackable {
+		if !itemType.Valid() {
+			t.Errorf("%s should be a valid type", itemType)
+		}
+		if got := itemType.Stackable(); got != want {
+			t.Errorf("%s.Stackable() = %v, want %v", itemType, got, want)
+		}
+	}
+}
+
+func TestSendShopError(t *testing.T) {
+	testErrorStatuses(t, (*PlantHandler).sendShopError, []errorStatus{
+		{infrastructure.ErrShopItemNotFound, http.StatusNotFound},
+		{infrastructure.ErrItemAlreadyOwned, http.StatusConflict},
+		{infrastructure.ErrItemNotStackable, http.StatusBadRequest},
+		{infrastructure.ErrPurchaseKeyReused, http.StatusUnprocessableEntity},
+		{infrastructure.ErrInsufficientCoins, http.StatusConflict},
+		{fmt.Errorf("connection reset"), http.StatusInternalServerError},
+	})
+}
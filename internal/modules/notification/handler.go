@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"plantgo-backend/internal/modules/auth"
 	"plantgo-backend/internal/modules/notification/infrastructure"
 )
 
@@ -29,26 +30,24 @@ type Response struct {
 // @Summary      Get user notifications
 // @Description  Retrieves paginated notifications for a user
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
-// @Param        userId path int true "User ID"
 // @Param        limit query int false "Number of notifications per page" default(20)
 // @Param        offset query int false "Offset for pagination" default(0)
 // @Success      200 {object} Response
-// @Failure      400 {object} Response
+// @Failure      401 {object} Response
 // @Failure      500 {object} Response
-// @Router       /notifications/{userId} [get]
+// @Router       /notifications/me [get]
 func (h *NotificationHandler) GetUserNotifications(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	notifications, err := h.service.GetUserNotifications(uint(userID), limit, offset)
+	notifications, err := h.service.GetUserNotifications(userID, limit, offset)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to fetch notifications", err)
 		return
@@ -61,21 +60,19 @@ func (h *NotificationHandler) GetUserNotifications(c *gin.Context) {
 // @Summary      Get unread notifications
 // @Description  Retrieves all unread notifications for a user
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
-// @Param        userId path int true "User ID"
 // @Success      200 {object} Response
-// @Failure      400 {object} Response
+// @Failure      401 {object} Response
 // @Failure      500 {object} Response
-// @Router       /notifications/{userId}/unread [get]
+// @Router       /notifications/me/unread [get]
 func (h *NotificationHandler) GetUnreadNotifications(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
-	notifications, err := h.service.GetUnreadNotifications(uint(userID))
+	notifications, err := h.service.GetUnreadNotifications(userID)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to fetch unread notifications", err)
 		return
@@ -88,21 +85,19 @@ func (h *NotificationHandler) GetUnreadNotifications(c *gin.Context) {
 // @Summary      Get unread notification count
 // @Description  Retrieves the count of unread notifications for a user
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
-// @Param        userId path int true "User ID"
 // @Success      200 {object} Response
-// @Failure      400 {object} Response
+// @Failure      401 {object} Response
 // @Failure      500 {object} Response
-// @Router       /notifications/{userId}/count [get]
+// @Router       /notifications/me/count [get]
 func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
-	count, err := h.service.GetUnreadNotificationCount(uint(userID))
+	count, err := h.service.GetUnreadNotificationCount(userID)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to fetch unread count", err)
 		return
@@ -117,6 +112,7 @@ func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
 // @Summary      Mark notification as read
 // @Description  Marks a specific notification as read
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
 // @Param        id path int true "Notification ID"
 // @Success      200 {object} Response
@@ -131,6 +127,10 @@ func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeNotification(c, uint(id)) {
+		return
+	}
+
 	err = h.service.MarkAsRead(uint(id))
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to mark notification as read", err)
@@ -144,21 +144,19 @@ func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
 // @Summary      Mark all notifications as read
 // @Description  Marks all notifications as read for a user
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
-// @Param        userId path int true "User ID"
 // @Success      200 {object} Response
-// @Failure      400 {object} Response
+// @Failure      401 {object} Response
 // @Failure      500 {object} Response
-// @Router       /notifications/{userId}/read-all [put]
+// @Router       /notifications/me/read-all [put]
 func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
-	err = h.service.MarkAllAsRead(uint(userID))
+	err := h.service.MarkAllAsRead(userID)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to mark all notifications as read", err)
 		return
@@ -171,6 +169,7 @@ func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 // @Summary      Delete notification
 // @Description  Deletes a specific notification
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
 // @Param        id path int true "Notification ID"
 // @Success      200 {object} Response
@@ -185,6 +184,10 @@ func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
 		return
 	}
 
+	if !h.authorizeNotification(c, uint(id)) {
+		return
+	}
+
 	err = h.service.DeleteNotification(uint(id))
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to delete notification", err)
@@ -198,6 +201,7 @@ func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
 // @Summary      Update FCM token
 // @Description  Updates the FCM token for push notifications
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        request body FCMTokenRequest true "FCM token update request"
@@ -212,7 +216,13 @@ func (h *NotificationHandler) UpdateFCMToken(c *gin.Context) {
 		return
 	}
 
-	err := h.service.UpdateFCMToken(req.UserID, req.Token)
+	userID, ok := h.resolveActingUser(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	sessionID, _ := auth.CurrentSessionID(c)
+	err := h.service.UpdateFCMToken(userID, sessionID, req.Token)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to update FCM token", err)
 		return
@@ -221,24 +231,11 @@ func (h *NotificationHandler) UpdateFCMToken(c *gin.Context) {
 	h.sendSuccess(c, "FCM token updated successfully", nil)
 }
 
-// GetNotificationsWithFilters godoc
-// @Summary      Get user notifications with filters
-// @Description  Retrieves paginated notifications for a user with type filtering
-// @Tags         Notifications
-// @Produce      json
-// @Param        userId path int true "User ID"
-// @Param        type query string false "Notification type filter" Enums(all,friend_request,game_reward,level_complete,daily_login_reward,weekly_challenge,achievement_unlocked,system_announcement,plant_identified)
-// @Param        limit query int false "Number of notifications per page" default(20)
-// @Param        offset query int false "Offset for pagination" default(0)
-// @Success      200 {object} Response
-// @Failure      400 {object} Response
-// @Failure      500 {object} Response
-// @Router       /notifications/{userId} [get]
+// GetNotificationsWithFilters returns the caller's notifications of one type
+// (or "all") with the total and unread counts. It is not mounted on a route.
 func (h *NotificationHandler) GetNotificationsWithFilters(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
@@ -247,13 +244,13 @@ func (h *NotificationHandler) GetNotificationsWithFilters(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	notifications, totalCount, err := h.service.GetNotificationsWithFilters(uint(userID), notificationType, limit, offset)
+	notifications, totalCount, err := h.service.GetNotificationsWithFilters(userID, notificationType, limit, offset)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to fetch notifications", err)
 		return
 	}
 
-	unreadCount, _ := h.service.GetUnreadNotificationCount(uint(userID))
+	unreadCount, _ := h.service.GetUnreadNotificationCount(userID)
 	hasMore := offset+limit < int(totalCount)
 
 	response := map[string]interface{}{
@@ -270,21 +267,19 @@ func (h *NotificationHandler) GetNotificationsWithFilters(c *gin.Context) {
 // @Summary      Get user notification preferences
 // @Description  Retrieves notification preferences for a user
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Produce      json
-// @Param        userId path int true "User ID"
 // @Success      200 {object} Response
-// @Failure      400 {object} Response
+// @Failure      401 {object} Response
 // @Failure      500 {object} Response
-// @Router       /notifications/{userId}/preferences [get]
+// @Router       /notifications/me/preferences [get]
 func (h *NotificationHandler) GetUserPreferences(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
-	preferences, err := h.service.GetUserPreferences(uint(userID))
+	preferences, err := h.service.GetUserPreferences(userID)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to get preferences", err)
 		return
@@ -297,19 +292,17 @@ func (h *NotificationHandler) GetUserPreferences(c *gin.Context) {
 // @Summary      Update user notification preferences
 // @Description  Updates notification preferences for a user
 // @Tags         Notifications
+// @Security     ApiKeyAuth
 // @Accept       json
 // @Produce      json
-// @Param        userId path int true "User ID"
 // @Param        preferences body infrastructure.UserNotificationPreference true "User preferences"
 // @Success      200 {object} Response
 // @Failure      400 {object} Response
 // @Failure      500 {object} Response
-// @Router       /notifications/{userId}/preferences [put]
+// @Router       /notifications/me/preferences [put]
 func (h *NotificationHandler) UpdateUserPreferences(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		h.sendError(c, http.StatusBadRequest, "Invalid user ID", err)
+	userID, ok := h.actingUserID(c)
+	if !ok {
 		return
 	}
 
@@ -319,7 +312,15 @@ func (h *NotificationHandler) UpdateUserPreferences(c *gin.Context) {
 		return
 	}
 
-	preferences.UserID = uint(userID)
+	// Never trust a row ID from the body; always update the caller's own row.
+	existing, err := h.service.GetUserPreferences(userID)
+	if err != nil {
+		h.sendError(c, http.StatusInternalServerError, "Failed to get preferences", err)
+		return
+	}
+	preferences.ID = existing.ID
+	preferences.UserID = userID
+	preferences.CreatedAt = existing.CreatedAt
 	err = h.service.UpdateUserPreferences(&preferences)
 	if err != nil {
 		h.sendError(c, http.StatusInternalServerError, "Failed to update preferences", err)
@@ -330,8 +331,9 @@ func (h *NotificationHandler) UpdateUserPreferences(c *gin.Context) {
 }
 
 // Request/Response structures
+// UserID is optional; when set it must match the authenticated user.
 type FCMTokenRequest struct {
-	UserID uint   `json:"user_id" binding:"required"`
+	UserID uint   `json:"user_id,omitempty"`
 	Token  string `json:"token" binding:"required"`
 }
 
@@ -347,6 +349,40 @@ type UpdatePreferencesRequest struct {
 }
 
 // Helper methods
+
+// actingUserID resolves the user a request acts on. Routes under /me carry no
+// userId parameter and act on the caller; legacy routes carry one that must
+// match the caller.
+func (h *NotificationHandler) actingUserID(c *gin.Context) (uint, bool) {
+	userID, err := auth.ActingUserFromPath(c)
+	if err != nil {
+		status, message := auth.ActingUserStatus(err)
+		h.sendError(c, status, message, err)
+		return 0, false
+	}
+	return userID, true
+}
+
+func (h *NotificationHandler) resolveActingUser(c *gin.Context, requestedID uint) (uint, bool) {
+	userID, err := auth.ActingUserID(c, requestedID)
+	if err != nil {
+		status, message := auth.ActingUserStatus(err)
+		h.sendError(c, status, message, err)
+		return 0, false
+	}
+	return userID, true
+}
+
+// authorizeNotification checks that the notification exists and belongs to the caller.
+func (h *NotificationHandler) authorizeNotification(c *gin.Context, notificationID uint) bool {
+	notification, err := h.service.GetNotificationByID(notificationID)
+	if err != nil {
+		h.sendError(c, http.StatusNotFound, "Notification not found", err)
+		return false
+	}
+	_, ok := h.resolveActingUser(c, notification.UserID)
+	return ok
+}
 func (h *NotificationHandler) sendError(c *gin.Context, statusCode int, message string, err error) {
 	response := Response{
 		Success: false,
@@ -16,6 +16,9 @@ const (
 	AchievementUnlocked NotificationType = "achievement_unlocked"
 	SystemAnnouncement  NotificationType = "system_announcement"
 	PlantIdentified     NotificationType = "plant_identified"
+	// ShopPurchase is a purchase receipt; it has no preference and is always
+	// sent.
+	ShopPurchase NotificationType = "shop_purchase"
 )
 
 type NotificationStatus string
@@ -60,9 +63,12 @@ type UserNotificationPreference struct {
 	UpdatedAt           time.Time `json:"updated_at"`
 }
 
+// UserFCMToken is a device's push token. SessionID ties it to the login
+// session on that device; tokens registered before sessions existed have none.
 type UserFCMToken struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	SessionID *uint     `json:"session_id,omitempty" gorm:"index"`
 	Token     string    `json:"token" gorm:"not null"`
 	IsActive  bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt time.Time `json:"created_at"`
@@ -108,7 +108,7 @@ func (r *NotificationRepository) GetNotificationsByType(userID uint, notificatio
 // FCM Token management
 func (r *NotificationRepository) UpsertFCMToken(userID uint, token string) error {
 	var fcmToken UserFCMToken
-	err := r.db.Where("user_id = ?", userID).First(&fcmToken).Error
+	err := r.db.Where("user_id = ? AND session_id IS NULL", userID).First(&fcmToken).Error
 	
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -129,9 +129,39 @@ func (r *NotificationRepository) UpsertFCMToken(userID uint, token string) error
 	return r.db.Save(&fcmToken).Error
 }
 
+// UpsertSessionFCMToken stores the push token for one signed-in device.
+func (r *NotificationRepository) UpsertSessionFCMToken(userID, sessionID uint, token string) error {
+	var fcmToken UserFCMToken
+	err := r.db.Where("user_id = ? AND session_id = ?", userID, sessionID).First(&fcmToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			fcmToken = UserFCMToken{
+				UserID:    userID,
+				SessionID: &sessionID,
+				Token:     token,
+				IsActive:  true,
+			}
+			return r.db.Create(&fcmToken).Error
+		}
+		return err
+	}
+
+	fcmToken.Token = token
+	fcmToken.IsActive = true
+	return r.db.Save(&fcmToken).Error
+}
+
+// DeactivateSessionFCMToken stops pushes to the device of a revoked session.
+func (r *NotificationRepository) DeactivateSessionFCMToken(sessionID uint) error {
+	return r.db.Model(&UserFCMToken{}).
+		Where("session_id = ?", sessionID).
+		Update("is_active", false).Error
+}
+
+// GetUserFCMToken returns the user's most recently registered active token.
 func (r *NotificationRepository) GetUserFCMToken(userID uint) (string, error) {
 	var fcmToken UserFCMToken
-	err := r.db.Where("user_id = ? AND is_active = true", userID).First(&fcmToken).Error
+	err := r.db.Where("user_id = ? AND is_active = true", userID).Order("updated_at DESC").First(&fcmToken).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return "", errors.New("FCM token not found")
@@ -298,6 +298,37 @@ func (s *NotificationService) GenerateGameRewardNotification(userID uint, reward
 	return s.createAndSendNotification(notification)
 }
 
+// GenerateShopPurchaseNotification confirms a purchase and the coins it
+// cost.
+func (s *NotificationService) GenerateShopPurchaseNotification(userID uint, itemName string, quantity, cost int) error {
+	reward := -cost
+	data := NotificationData{
+		Reward: &reward,
+		ExtraData: map[string]interface{}{
+			"item_name": itemName,
+			"quantity":  quantity,
+			"cost":      cost,
+		},
+	}
+
+	dataJSON, _ := json.Marshal(data)
+
+	item := itemName
+	if quantity > 1 {
+		item = fmt.Sprintf("%d × %s", quantity, itemName)
+	}
+	notification := &infrastructure.Notification{
+		UserID:  userID,
+		Type:    infrastructure.ShopPurchase,
+		Title:   "Purchase Complete! 🛍️",
+		Message: fmt.Sprintf("You bought %s for %d coins.", item, cost),
+		Data:    string(dataJSON),
+		Status:  infrastructure.Pending,
+	}
+
+	return s.createAndSendNotification(notification)
+}
+
 // Bulk notification generation for system announcements
 func (s *NotificationService) GenerateBulkSystemAnnouncement(userIDs []uint, title, message string) error {
 	notifications := make([]*infrastructure.Notification, 0, len(userIDs))
@@ -345,6 +376,10 @@ func (s *NotificationService) GetUnreadNotificationCount(userID uint) (int64, er
 	return s.repo.GetUnreadNotificationCount(userID)
 }
 
+func (s *NotificationService) GetNotificationByID(notificationID uint) (*infrastructure.Notification, error) {
+	return s.repo.GetNotificationByID(notificationID)
+}
+
 func (s *NotificationService) MarkAsRead(notificationID uint) error {
 	return s.repo.MarkAsRead(notificationID)
 }
@@ -357,8 +392,14 @@ func (s *NotificationService) DeleteNotification(notificationID uint) error {
 	return s.repo.DeleteNotification(notificationID)
 }
 
-func (s *NotificationService) UpdateFCMToken(userID uint, token string) error {
-	return s.repo.UpsertFCMToken(userID, token)
+// UpdateFCMToken stores the push token for the caller's device. Access tokens
+// minted before sessions existed have no session ID and fall back to one
+// token per user.
+func (s *NotificationService) UpdateFCMToken(userID, sessionID uint, token string) error {
+	if sessionID == 0 {
+		return s.repo.UpsertFCMToken(userID, token)
+	}
+	return s.repo.UpsertSessionFCMToken(userID, sessionID, token)
 }
 
 func (s *NotificationService) GetUserPreferences(userID uint) (*infrastructure.UserNotificationPreference, error) {
@@ -11,11 +11,13 @@ import (
 	_ "plantgo-backend/cmd/api/docs"
 	"plantgo-backend/internal/database"
 	"plantgo-backend/internal/modules/auth"
+	authinfra "plantgo-backend/internal/modules/auth/infrastructure"
 	"plantgo-backend/internal/modules/level"
 	"plantgo-backend/internal/modules/level/infrastructure"
 	"plantgo-backend/internal/modules/notification"
 	notificationinfra "plantgo-backend/internal/modules/notification/infrastructure"
 	"plantgo-backend/internal/modules/plant"
+	"plantgo-backend/internal/storage"
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
@@ -24,7 +26,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key"},
 		AllowCredentials: true,
 	}))
 
@@ -54,9 +56,20 @@ func (s *Server) RegisterRoutes() http.Handler {
 	scanService := plant.NewScanService(notificationService)
 	
 	// Initialize handlers
-	plantHandler := level.NewPlantHandler(plantRepository, notificationService)
+	plantHandler := level.NewPlantHandler(plantRepository, notificationService, scanService)
 	notificationHandler := notification.NewNotificationHandler(notificationService)
 
+	requireAuth := authService.AuthMiddleware()
+	requireEditor := auth.RequireRole(authinfra.RoleContentEditor, authinfra.RoleAdmin)
+	requireAdmin := auth.RequireRole(authinfra.RoleAdmin)
+
+	r.GET("/.well-known/jwks.json", authService.JWKSHandler)
+
+	// Uploads kept on local disk, such as avatars, are served directly.
+	if dir := storage.LocalDirFromEnv(); dir != "" {
+		r.Static(storage.LocalURLPrefix, dir)
+	}
+
 	// API v1 routes
 	api := r.Group("/api/v1")
 	
@@ -64,11 +77,48 @@ func (s *Server) RegisterRoutes() http.Handler {
 	authGroup := api.Group("/auth")
 	{
 		authGroup.POST("/guest", authService.GuestLoginHandler)
-		authGroup.POST("/google", authService.GoogleLoginHandler)
-		authGroup.POST("/google/callback", authService.GoogleCallbackHandler)
+		authGroup.GET("/google/login", authService.GoogleLoginHandler)
+		authGroup.GET("/google/callback", authService.GoogleCallbackHandler)
+		authGroup.POST("/google/token", authService.GoogleIDTokenHandler)
+		authGroup.POST("/apple/token", authService.AppleSignInHandler)
 		authGroup.POST("/register", authService.RegisterHandler)
 		authGroup.POST("/login", authService.LoginHandler)
-		authGroup.GET("/profile", authService.GetProfileHandler)
+		authGroup.POST("/refresh", authService.RefreshHandler)
+		authGroup.POST("/logout", authService.LogoutHandler)
+		authGroup.POST("/logout-all", requireAuth, authService.LogoutAllHandler)
+		authGroup.GET("/sessions", requireAuth, authService.ListSessionsHandler)
+		authGroup.DELETE("/sessions", requireAuth, authService.RevokeOtherSessionsHandler)
+		authGroup.DELETE("/sessions/:id", requireAuth, authService.RevokeSessionHandler)
+		authGroup.POST("/account/deletion", requireAuth, authService.RequestAccountDeletionHandler)
+		authGroup.DELETE("/account/deletion", requireAuth, authService.CancelAccountDeletionHandler)
+		authGroup.GET("/account/export", requireAuth, authService.ExportAccountDataHandler)
+		authGroup.POST("/link/email", requireAuth, authService.LinkEmailHandler)
+		authGroup.POST("/link/google", requireAuth, authService.LinkGoogleHandler)
+		authGroup.POST("/link/apple", requireAuth, authService.LinkAppleHandler)
+		authGroup.POST("/device/bind", requireAuth, authService.BindDeviceHandler)
+		authGroup.POST("/2fa/verify", authService.VerifyTwoFactorHandler)
+		authGroup.POST("/2fa/totp/setup", requireAuth, authService.SetupTOTPHandler)
+		authGroup.POST("/2fa/totp/confirm", requireAuth, authService.ConfirmTOTPHandler)
+		authGroup.POST("/2fa/totp/disable", requireAuth, authService.DisableTOTPHandler)
+		authGroup.POST("/2fa/recovery-codes", requireAuth, authService.RegenerateRecoveryCodesHandler)
+		authGroup.GET("/identities", requireAuth, authService.ListIdentitiesHandler)
+		authGroup.DELETE("/identities/:provider", requireAuth, authService.UnlinkIdentityHandler)
+		authGroup.POST("/email/verify/request", requireAuth, authService.RequestEmailVerificationHandler)
+		authGroup.POST("/email/verify", authService.VerifyEmailHandler)
+		authGroup.POST("/password/forgot", authService.ForgotPasswordHandler)
+		authGroup.POST("/password/reset", authService.ResetPasswordHandler)
+		authGroup.POST("/password/change", requireAuth, authService.ChangePasswordHandler)
+		authGroup.GET("/profile", requireAuth, authService.GetProfileHandler)
+	}
+
+	// The caller's own profile
+	meGroup := api.Group("/me")
+	meGroup.Use(requireAuth)
+	{
+		meGroup.GET("", authService.GetProfileHandler)
+		meGroup.PATCH("", authService.UpdateProfileHandler)
+		meGroup.POST("/avatar", authService.UploadAvatarHandler)
+		meGroup.DELETE("/avatar", authService.DeleteAvatarHandler)
 	}
 
 	// Plant/Level routes
@@ -77,41 +127,36 @@ func (s *Server) RegisterRoutes() http.Handler {
 		levelGroup.GET("/", plantHandler.GetAllLevels)
 		levelGroup.GET("/:id", plantHandler.GetLevel)
 		levelGroup.GET("/number/:number", plantHandler.GetLevelByNumber)
-		levelGroup.POST("/complete", plantHandler.CompleteLevel)
-		levelGroup.POST("/complete-by-number", plantHandler.CompleteLevelByNumber)
-		levelGroup.GET("/user/:userId/progress", plantHandler.GetUserProgress)
-		levelGroup.GET("/user/:userId/completed", plantHandler.GetCompletedLevels)
-		levelGroup.GET("/user/:userId/reward", plantHandler.GetUserReward)
-		levelGroup.GET("/details/:id", plantHandler.GetLevelDetails)
-		levelGroup.GET("/game-data", plantHandler.GetGameData)
-		levelGroup.POST("/", plantHandler.CreateLevel)
-		levelGroup.PUT("/:id", plantHandler.UpdateLevel)
-		levelGroup.DELETE("/:id", plantHandler.DeleteLevel)
+		levelGroup.POST("/complete", requireAuth, plantHandler.CompleteLevel)
+		levelGroup.POST("/complete-by-number", requireAuth, plantHandler.CompleteLevelByNumber)
+		levelGroup.POST("/answer", requireAuth, plantHandler.SubmitAnswer)
+		levelGroup.POST("/:id/scan", requireAuth, plantHandler.VerifyLevelByScan)
+		levelGroup.GET("/:id/hints", requireAuth, plantHandler.GetLevelHints)
+		levelGroup.POST("/:id/hints/unlock", requireAuth, plantHandler.UnlockHint)
+		levelGroup.GET("/user/:userId/progress", requireAuth, plantHandler.GetUserProgress)
+		levelGroup.GET("/user/:userId/completed", requireAuth, plantHandler.GetCompletedLevels)
+		levelGroup.GET("/user/:userId/reward", requireAuth, plantHandler.GetUserReward)
+		levelGroup.GET("/details/:number", requireAuth, plantHandler.GetLevelDetails)
+		levelGroup.GET("/game-data", requireAuth, plantHandler.GetGameData)
+		levelGroup.POST("/", requireAuth, requireEditor, plantHandler.CreateLevel)
+		levelGroup.PUT("/:id", requireAuth, requireEditor, plantHandler.UpdateLevel)
+		levelGroup.DELETE("/:id", requireAuth, requireAdmin, plantHandler.DeleteLevel)
 	}
 
 	// Plant scanning routes
 	plantGroup := api.Group("/plants")
 	{
-		plantGroup.POST("/scan", scanService.ScanImageHandler)
+		plantGroup.POST("/scan", authService.OptionalAuthMiddleware(), scanService.ScanImageHandler)
 	}
 
 	// Notification routes
 	notificationGroup := api.Group("/notifications")
-	{
-		notificationGroup.GET("/:userId", notificationHandler.GetUserNotifications)
-		notificationGroup.GET("/:userId/unread", notificationHandler.GetUnreadNotifications)
-		notificationGroup.GET("/:userId/unread/count", notificationHandler.GetUnreadCount)
-		notificationGroup.PUT("/:id/read", notificationHandler.MarkAsRead)
-		notificationGroup.PUT("/:userId/read-all", notificationHandler.MarkAllAsRead)
-		notificationGroup.DELETE("/:id", notificationHandler.DeleteNotification)
-		notificationGroup.POST("/fcm-token", notificationHandler.UpdateFCMToken)
-		notificationGroup.GET("/:userId/preferences", notificationHandler.GetUserPreferences)
-		notificationGroup.PUT("/:userId/preferences", notificationHandler.UpdateUserPreferences)
-	}
+	notificationGroup.Use(requireAuth)
+	registerNotificationRoutes(notificationGroup, notificationHandler)
 
 	// Protected routes
 	authorized := r.Group("/")
-	// authorized.Use(AuthMiddleware()) // Uncomment when you have auth middleware
+	authorized.Use(authService.AuthMiddleware())
 	{
 		// User profile
 		authorized.GET("/profile", authService.GetProfileHandler)
@@ -126,6 +171,21 @@ func (s *Server) RegisterRoutes() http.Handler {
 			gameGroup.GET("/rewards/:userId", plantHandler.GetUserReward)
 			gameGroup.POST("/complete", plantHandler.CompleteLevel)
 			gameGroup.POST("/complete-by-number", plantHandler.CompleteLevelByNumber)
+
+			// The caller's own game state, resolved from the access token
+			gameGroup.GET("/me/data", plantHandler.GetGameData)
+			gameGroup.GET("/me/level/:number", plantHandler.GetLevelDetails)
+			gameGroup.GET("/me/progress", plantHandler.GetUserProgress)
+			gameGroup.GET("/me/completed", plantHandler.GetCompletedLevels)
+			gameGroup.GET("/me/rewards", plantHandler.GetUserReward)
+			gameGroup.POST("/me/answer", plantHandler.SubmitAnswer)
+			gameGroup.POST("/me/levels/:id/scan", plantHandler.VerifyLevelByScan)
+			gameGroup.GET("/me/levels/:id/hints", plantHandler.GetLevelHints)
+			gameGroup.POST("/me/levels/:id/hints/unlock", plantHandler.UnlockHint)
+			gameGroup.GET("/me/coins/transactions", plantHandler.GetCoinTransactions)
+			gameGroup.GET("/me/inventory", plantHandler.GetInventory)
+			gameGroup.POST("/me/shop/purchase", plantHandler.PurchaseShopItem)
+			gameGroup.GET("/shop/items", plantHandler.GetShopItems)
 		}
 
 		// Level routes (general access)
@@ -136,28 +196,34 @@ func (s *Server) RegisterRoutes() http.Handler {
 			levelGroup.GET("/number/:number", plantHandler.GetLevelByNumber)
 		}
 
-		// Admin routes (level management)
+		// Admin routes. Content editors may author levels; everything
+		// destructive or touching accounts is admin only.
 		adminGroup := authorized.Group("/admin")
-		// adminGroup.Use(AdminMiddleware()) // Add admin middleware when available
+		adminGroup.Use(requireEditor)
 		{
+			adminGroup.GET("/levels", plantHandler.GetAdminLevels)
+			adminGroup.GET("/levels/:id", plantHandler.GetAdminLevel)
 			adminGroup.POST("/levels", plantHandler.CreateLevel)
 			adminGroup.PUT("/levels/:id", plantHandler.UpdateLevel)
-			adminGroup.DELETE("/levels/:id", plantHandler.DeleteLevel)
+			adminGroup.DELETE("/levels/:id", requireAdmin, plantHandler.DeleteLevel)
+			adminGroup.GET("/levels/:id/hints", plantHandler.GetAdminLevelHints)
+			adminGroup.POST("/levels/:id/hints", plantHandler.CreateHint)
+			adminGroup.PUT("/hints/:id", plantHandler.UpdateHint)
+			adminGroup.DELETE("/hints/:id", requireAdmin, plantHandler.DeleteHint)
+			adminGroup.PUT("/users/:id/role", requireAdmin, authService.UpdateUserRoleHandler)
+			adminGroup.GET("/users/:id/coins/transactions", requireAdmin, plantHandler.GetUserCoinTransactions)
+			adminGroup.POST("/users/:id/coins/adjust", requireAdmin, plantHandler.AdjustUserCoins)
+			adminGroup.POST("/users/:id/coins/reconcile", requireAdmin, plantHandler.ReconcileUserCoins)
+			adminGroup.POST("/coin-transactions/:id/reverse", requireAdmin, plantHandler.ReverseCoinTransaction)
+			adminGroup.GET("/coins/drift", requireAdmin, plantHandler.GetBalanceDrift)
+			adminGroup.GET("/shop/items", requireAdmin, plantHandler.GetAllShopItems)
+			adminGroup.POST("/shop/items", requireAdmin, plantHandler.CreateShopItem)
+			adminGroup.PUT("/shop/items/:id", requireAdmin, plantHandler.UpdateShopItem)
+			adminGroup.DELETE("/shop/items/:id", requireAdmin, plantHandler.DeleteShopItem)
 		}
 
 		// Notification routes
-		notificationGroup := authorized.Group("/notifications")
-		{
-			notificationGroup.GET("/:userId", notificationHandler.GetUserNotifications)
-			notificationGroup.GET("/:userId/unread", notificationHandler.GetUnreadNotifications)
-			notificationGroup.GET("/:userId/count", notificationHandler.GetUnreadCount)
-			notificationGroup.PUT("/:id/read", notificationHandler.MarkAsRead)
-			notificationGroup.PUT("/:userId/read-all", notificationHandler.MarkAllAsRead)
-			notificationGroup.DELETE("/:id", notificationHandler.DeleteNotification)
-			notificationGroup.POST("/fcm-token", notificationHandler.UpdateFCMToken)
-			notificationGroup.GET("/:userId/preferences", notificationHandler.GetUserPreferences)
-			notificationGroup.PUT("/:userId/preferences", notificationHandler.UpdateUserPreferences)
-		}
+		registerNotificationRoutes(authorized.Group("/notifications"), notificationHandler)
 	}
 
 	// Health check for the plant service
@@ -166,6 +232,40 @@ func (s *Server) RegisterRoutes() http.Handler {
 	return r
 }
 
+// registerNotificationRoutes mounts the notification endpoints on an
+// authenticated group. The /me routes act on the caller; the legacy :userId
+// routes are kept for older clients and are checked against the token.
+func registerNotificationRoutes(group *gin.RouterGroup, handler *notification.NotificationHandler) {
+	group.GET("/me", handler.GetUserNotifications)
+	group.GET("/me/unread", handler.GetUnreadNotifications)
+	group.GET("/me/count", handler.GetUnreadCount)
+	group.PUT("/me/read-all", handler.MarkAllAsRead)
+	group.GET("/me/preferences", handler.GetUserPreferences)
+	group.PUT("/me/preferences", handler.UpdateUserPreferences)
+
+	group.GET("/:userId", handler.GetUserNotifications)
+	group.GET("/:userId/unread", handler.GetUnreadNotifications)
+	group.GET("/:userId/count", handler.GetUnreadCount)
+	group.GET("/:userId/unread/count", handler.GetUnreadCount)
+	group.GET("/:userId/preferences", handler.GetUserPreferences)
+
+	group.PUT("/:id/read", handler.MarkAsRead)
+	group.DELETE("/:id", handler.DeleteNotification)
+	group.POST("/fcm-token", handler.UpdateFCMToken)
+
+	// gin needs PUT routes to share the :id wildcard, so these take the user
+	// ID as :id and hand it on as :userId.
+	group.PUT("/:id/read-all", userIDFromID, handler.MarkAllAsRead)
+	group.PUT("/:id/preferences", userIDFromID, handler.UpdateUserPreferences)
+}
+
+// userIDFromID exposes the route's :id parameter as :userId, which
+// auth.ActingUserFromPath checks against the caller.
+func userIDFromID(c *gin.Context) {
+	c.Params = append(c.Params, gin.Param{Key: "userId", Value: c.Param("id")})
+	c.Next()
+}
+
 // HelloWorldHandler godoc
 // @Summary      Hello World
 // @Description  Basic test route
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"plantgo-backend/internal/modules/notification"
 )
 
 func TestHelloWorldHandler(t *testing.T) {
@@ -30,3 +32,25 @@ func TestHelloWorldHandler(t *testing.T) {
 		t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
 	}
 }
+
+func TestRegisterNotificationRoutes(t *testing.T) {
+	// Legacy :userId routes share path segments with :id routes; gin panics
+	// on conflicting wildcards, so make sure the table registers cleanly.
+	r := gin.New()
+	registerNotificationRoutes(r.Group("/notifications"), &notification.NotificationHandler{})
+
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for _, expected := range []string{
+		"GET /notifications/me",
+		"GET /notifications/:userId",
+		"PUT /notifications/me/read-all",
+		"PUT /notifications/:id/read",
+	} {
+		if !routes[expected] {
+			t.Errorf("route %q not registered", expected)
+		}
+	}
+}
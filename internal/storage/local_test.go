@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorePutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "https://cdn.example.com/files/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, err := store.Put(context.Background(), "avatars/7/a.png", []byte("png"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://cdn.example.com/files/avatars/7/a.png" {
+		t.Errorf("unexpected URL %q", url)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "avatars", "7", "a.png"))
+	if err != nil || string(data) != "png" {
+		t.Fatalf("file not written: %v", err)
+	}
+
+	if err := store.Delete(context.Background(), "avatars/7/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "avatars", "7", "a.png")); !os.IsNotExist(err) {
+		t.Error("expected file to be removed")
+	}
+	if err := store.Delete(context.Background(), "avatars/7/a.png"); err != nil {
+		t.Errorf("deleting a missing file should succeed, got %v", err)
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "../secret", "avatars/../../x", "/abs", "a//b"} {
+		if _, err := store.Put(context.Background(), key, []byte("x"), ""); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Package storage keeps user uploaded files such as avatars.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Store saves files under a key and returns the URL clients load them from.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) (url string, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+const defaultLocalDir = "uploads"
+
+// LocalURLPrefix is the path the server serves the local store's files under.
+const LocalURLPrefix = "/uploads"
+
+// NewStoreFromEnv picks a store based on STORAGE_DRIVER. Only "local" (the
+// default) is supported for now.
+func NewStoreFromEnv() (Store, error) {
+	switch driver := strings.ToLower(os.Getenv("STORAGE_DRIVER")); driver {
+	case "", "local":
+		return NewLocalStore(LocalDirFromEnv(), localPublicURLFromEnv())
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
+
+// LocalDirFromEnv returns the directory the local store writes to, or "" if
+// another driver is configured. The server serves it at LocalURLPrefix.
+func LocalDirFromEnv() string {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "", "local":
+	default:
+		return ""
+	}
+	if dir := os.Getenv("STORAGE_LOCAL_DIR"); dir != "" {
+		return dir
+	}
+	return defaultLocalDir
+}
+
+// localPublicURLFromEnv is the URL prefix put in front of keys. It defaults to
+// the path the server itself serves; set STORAGE_PUBLIC_URL when files are
+// fronted by a CDN or another host.
+func localPublicURLFromEnv() string {
+	if url := os.Getenv("STORAGE_PUBLIC_URL"); url != "" {
+		return strings.TrimRight(url, "/")
+	}
+	return LocalURLPrefix
+}